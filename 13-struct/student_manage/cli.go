@@ -0,0 +1,347 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// 退出码：1 为执行失败，2 为参数错误
+const (
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+func usageError(format string, a ...interface{}) error {
+	return cli.NewExitError(fmt.Sprintf(format, a...), exitUsage)
+}
+
+func failure(err error) error {
+	return cli.NewExitError(err.Error(), exitFailure)
+}
+
+// 按 --data 参数打开存储，为空时使用内存存储
+func openRepository(c *cli.Context) (StudentRepository, func(), error) {
+	path := c.GlobalString("data")
+	if path == "" {
+		return NewMemoryRepository(), func() {}, nil
+	}
+	repo, err := NewJSONRepository(path)
+	if err != nil {
+		return nil, nil, failure(err)
+	}
+	return repo, func() { _ = repo.Close() }, nil
+}
+
+// 包装需要存储的命令
+func withRepository(action func(c *cli.Context, repo StudentRepository) error) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		repo, closeRepo, err := openRepository(c)
+		if err != nil {
+			return err
+		}
+		defer closeRepo()
+		return action(c, repo)
+	}
+}
+
+// 列出学生
+func listStudents(c *cli.Context, repo StudentRepository) error {
+	format := c.String("output")
+	if format != FormatTable && format != FormatJSON && format != FormatCSV {
+		return usageError("Unknown output %q, must be one of table, json, csv", format)
+	}
+	students, err := repo.List()
+	if err != nil {
+		return failure(err)
+	}
+	if err = WriteStudents(os.Stdout, students, format); err != nil {
+		return failure(err)
+	}
+	return nil
+}
+
+// 新增学生，已存在时报错
+func addStudent(c *cli.Context, repo StudentRepository) error {
+	for _, name := range []string{"name", "sex", "score", "grade"} {
+		if !c.IsSet(name) {
+			return usageError("Missing required flag --%s", name)
+		}
+	}
+	score, err := ParseScore(c.String("score"))
+	if err != nil {
+		return usageError("%v", err)
+	}
+	stu := NewStudent(c.String("name"), c.String("sex"), score, c.String("grade"))
+	if err = stu.Validate(); err != nil {
+		return usageError("%v", err)
+	}
+	if _, err = repo.Get(stu.Name); err == nil {
+		return failure(fmt.Errorf("Student %q already exists, use edit to modify", stu.Name))
+	} else if err != ErrStudentNotFound {
+		return failure(err)
+	}
+	if err = repo.Save(stu); err != nil {
+		return failure(err)
+	}
+	fmt.Printf("Added student %q\n", stu.Name)
+	return nil
+}
+
+// 修改学生，只更新传入的字段
+func editStudent(c *cli.Context, repo StudentRepository) error {
+	if err := ValidateName(c.String("name")); err != nil {
+		return usageError("%v", err)
+	}
+	if !c.IsSet("sex") && !c.IsSet("score") && !c.IsSet("grade") {
+		return usageError("Nothing to edit, set at least one of --sex, --score, --grade")
+	}
+	stu, err := repo.Get(c.String("name"))
+	if err == ErrStudentNotFound {
+		return failure(fmt.Errorf("Student %q not found", c.String("name")))
+	}
+	if err != nil {
+		return failure(err)
+	}
+	if c.IsSet("sex") {
+		stu.Sex = c.String("sex")
+	}
+	if c.IsSet("score") {
+		if stu.Scope, err = ParseScore(c.String("score")); err != nil {
+			return usageError("%v", err)
+		}
+	}
+	if c.IsSet("grade") {
+		stu.Grade = c.String("grade")
+	}
+	if err = stu.Validate(); err != nil {
+		return usageError("%v", err)
+	}
+	if err = repo.Save(stu); err != nil {
+		return failure(err)
+	}
+	fmt.Printf("Updated student %q\n", stu.Name)
+	return nil
+}
+
+// 删除学生
+func removeStudent(c *cli.Context, repo StudentRepository) error {
+	name := c.String("name")
+	if err := ValidateName(name); err != nil {
+		return usageError("%v", err)
+	}
+	err := repo.Delete(name)
+	if err == ErrStudentNotFound {
+		return failure(fmt.Errorf("Student %q not found", name))
+	}
+	if err != nil {
+		return failure(err)
+	}
+	fmt.Printf("Deleted student %q\n", name)
+	return nil
+}
+
+// 从文件导入学生，全部校验通过后才写入，同名学生覆盖
+func importStudents(c *cli.Context, repo StudentRepository) error {
+	if c.NArg() != 1 {
+		return usageError("Usage: student_manage import [--format json|csv] <file>")
+	}
+	path := c.Args().First()
+	format := c.String("format")
+	if format == "" {
+		format = formatOf(path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return failure(err)
+	}
+	defer file.Close()
+	students, err := ReadStudents(file, format)
+	if err != nil {
+		return usageError("%s: %v", path, err)
+	}
+	if err = repo.SaveAll(students); err != nil {
+		return failure(err)
+	}
+	fmt.Printf("Imported %d students\n", len(students))
+	return nil
+}
+
+// 导出学生，未指定文件时输出到标准输出
+func exportStudents(c *cli.Context, repo StudentRepository) error {
+	if c.NArg() > 1 {
+		return usageError("Usage: student_manage export [--format json|csv] [file]")
+	}
+	path := c.Args().First()
+	format := c.String("format")
+	if format == "" {
+		format = formatOf(path)
+	}
+	if format != FormatJSON && format != FormatCSV {
+		return usageError("Unknown format %q, must be one of json, csv", format)
+	}
+	students, err := repo.List()
+	if err != nil {
+		return failure(err)
+	}
+	var w io.Writer = os.Stdout
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return failure(err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if err = WriteStudents(w, students, format); err != nil {
+		return failure(err)
+	}
+	return nil
+}
+
+// 输出统计报告
+func reportStudents(c *cli.Context, repo StudentRepository) error {
+	format := c.String("output")
+	if format != FormatTable && format != FormatJSON && format != FormatHTML {
+		return usageError("Unknown output %q, must be one of table, json, html", format)
+	}
+	opts := ReportOptions{PassScore: DefaultPassScore}
+	if c.IsSet("pass") {
+		score, err := ParseScore(c.String("pass"))
+		if err != nil {
+			return usageError("Invalid pass score: %v", err)
+		}
+		opts.PassScore = score
+	}
+	if c.IsSet("buckets") {
+		opts.Buckets = []int{}
+		for _, field := range strings.Split(c.String("buckets"), ",") {
+			if field = strings.TrimSpace(field); field == "" {
+				continue
+			}
+			bound, err := strconv.Atoi(field)
+			if err != nil {
+				return usageError("Invalid bucket %q, must be an integer", field)
+			}
+			opts.Buckets = append(opts.Buckets, bound)
+		}
+	}
+	students, err := repo.List()
+	if err != nil {
+		return failure(err)
+	}
+	report, err := NewReport(students, opts)
+	if err != nil {
+		return usageError("%v", err)
+	}
+	if err = WriteReport(os.Stdout, report, format); err != nil {
+		return failure(err)
+	}
+	return nil
+}
+
+// 启动 http 服务
+func serve(c *cli.Context, repo StudentRepository) error {
+	if err := NewServer(repo).Router().Run(c.String("addr")); err != nil {
+		return failure(fmt.Errorf("Gin server failed, err:%v", err))
+	}
+	return nil
+}
+
+// 学生字段参数
+func studentFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{Name: "name, n", Usage: "student name"},
+		cli.StringFlag{Name: "sex, s", Usage: fmt.Sprintf("student sex %v", Sexes)},
+		cli.StringFlag{Name: "score", Usage: fmt.Sprintf("score between %d and %d", MinScore, MaxScore)},
+		cli.StringFlag{Name: "grade, g", Usage: "student grade"},
+	}
+}
+
+func newApp() *cli.App {
+	app := cli.NewApp()
+	app.Name = "student_manage"
+	app.Usage = "manage students, runs the interactive menu without a command"
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "data, d",
+			Value: "students.json",
+			Usage: "students json file, empty to keep data in memory only",
+		},
+	}
+	app.Action = withRepository(func(c *cli.Context, repo StudentRepository) error {
+		runMenu(NewManager(repo), os.Stdin)
+		return nil
+	})
+	app.Commands = []cli.Command{
+		{
+			Name:  "list",
+			Usage: "list students",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "output, o", Value: FormatTable, Usage: "output format: table, json or csv"},
+			},
+			Action: withRepository(listStudents),
+		},
+		{
+			Name:   "add",
+			Usage:  "add a student, fails if the name exists",
+			Flags:  studentFlags(),
+			Action: withRepository(addStudent),
+		},
+		{
+			Name:   "edit",
+			Usage:  "edit the given fields of a student",
+			Flags:  studentFlags(),
+			Action: withRepository(editStudent),
+		},
+		{
+			Name:  "delete",
+			Usage: "delete a student",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "name, n", Usage: "student name"},
+			},
+			Action: withRepository(removeStudent),
+		},
+		{
+			Name:      "import",
+			Usage:     "import students from a json or csv file, existing names are overwritten",
+			ArgsUsage: "<file>",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "format, f", Usage: "json or csv, detected from the file extension by default"},
+			},
+			Action: withRepository(importStudents),
+		},
+		{
+			Name:      "export",
+			Usage:     "export students as json or csv",
+			ArgsUsage: "[file]",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "format, f", Usage: "json or csv, detected from the file extension by default"},
+			},
+			Action: withRepository(exportStudents),
+		},
+		{
+			Name:  "report",
+			Usage: "print per grade statistics, score histogram and ranking",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "output, o", Value: FormatTable, Usage: "output format: table, json or html"},
+				cli.StringFlag{Name: "pass", Usage: fmt.Sprintf("pass score, %d by default", DefaultPassScore)},
+				cli.StringFlag{Name: "buckets, b", Usage: "comma separated histogram bucket lower bounds, e.g. 60,70,80,90"},
+			},
+			Action: withRepository(reportStudents),
+		},
+		{
+			Name:  "serve",
+			Usage: "serve the students REST API",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "addr, a", Value: ":8888", Usage: "listen address"},
+			},
+			Action: withRepository(serve),
+		},
+	}
+	return app
+}
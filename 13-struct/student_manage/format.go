@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// 输出格式
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+// csv 表头
+var csvHeader = []string{"name", "sex", "score", "grade"}
+
+// 按文件扩展名推断格式，无法识别时返回 json
+func formatOf(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return FormatCSV
+	}
+	return FormatJSON
+}
+
+// 按格式输出学生列表
+func WriteStudents(w io.Writer, students []*Student, format string) error {
+	switch format {
+	case FormatTable:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tSEX\tSCORE\tGRADE")
+		for _, stu := range students {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", stu.Name, stu.Sex, stu.Scope, stu.Grade)
+		}
+		return tw.Flush()
+	case FormatJSON:
+		if students == nil {
+			students = []*Student{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(students)
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		_ = cw.Write(csvHeader)
+		for _, stu := range students {
+			_ = cw.Write([]string{stu.Name, stu.Sex, strconv.Itoa(stu.Scope), stu.Grade})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("Unknown format %q, must be one of table, json, csv", format)
+}
+
+// 按格式读取学生列表并逐条校验，姓名重复时返回错误
+func ReadStudents(r io.Reader, format string) ([]*Student, error) {
+	var (
+		students []*Student
+		err      error
+	)
+	switch format {
+	case FormatJSON:
+		if err = json.NewDecoder(r).Decode(&students); err != nil {
+			return nil, fmt.Errorf("Failed to decode json, err:%v", err)
+		}
+	case FormatCSV:
+		if students, err = readCSV(r); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("Unknown format %q, must be one of json, csv", format)
+	}
+	names := make(map[string]int, len(students))
+	for i, stu := range students {
+		if stu == nil {
+			return nil, fmt.Errorf("Record %d: empty student", i+1)
+		}
+		if err = stu.Validate(); err != nil {
+			return nil, fmt.Errorf("Record %d: %v", i+1, err)
+		}
+		if prev, ok := names[stu.Name]; ok {
+			return nil, fmt.Errorf("Record %d: duplicate name %q, first seen in record %d", i+1, stu.Name, prev)
+		}
+		names[stu.Name] = i + 1
+	}
+	return students, nil
+}
+
+// 按表头读取 csv，列顺序不限
+func readCSV(r io.Reader) ([]*Student, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read csv header, err:%v", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range csvHeader {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("Missing csv column %q", name)
+		}
+	}
+	var students []*Student
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return students, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read csv, err:%v", err)
+		}
+		score, err := ParseScore(record[columns["score"]])
+		if err != nil {
+			return nil, fmt.Errorf("Record %d: %v", len(students)+1, err)
+		}
+		students = append(students, NewStudent(record[columns["name"]], record[columns["sex"]], score, record[columns["grade"]]))
+	}
+}
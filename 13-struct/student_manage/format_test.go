@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReadWriteStudents(t *testing.T) {
+	students := []*Student{
+		NewStudent("张三", "男", 90, "一班"),
+		NewStudent("李,四", "female", 0, "二班"),
+	}
+	for _, format := range []string{FormatJSON, FormatCSV} {
+		var buf bytes.Buffer
+		if err := WriteStudents(&buf, students, format); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := ReadStudents(&buf, format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if len(loaded) != 2 || *loaded[0] != *students[0] || *loaded[1] != *students[1] {
+			t.Fatalf("%s: wrong students %v", format, loaded)
+		}
+	}
+}
+
+func TestReadStudentsInvalid(t *testing.T) {
+	cases := []struct {
+		format string
+		input  string
+		err    string
+	}{
+		{FormatCSV, "name,sex,score,grade\n张三,男,abc,一班\n", `Record 1: Invalid score "abc"`},
+		{FormatCSV, "name,sex,grade\n张三,男,一班\n", `Missing csv column "score"`},
+		{FormatCSV, "name,sex,score,grade\n张三,男,90,一班\n张三,男,80,一班\n", `Record 2: duplicate name "张三"`},
+		{FormatJSON, `[{"name":"张三","sex":"x","scope":90,"grade":"一班"}]`, `Record 1: Invalid sex "x"`},
+		{FormatJSON, `[{"name":"张三","sex":"男","scope":120,"grade":"一班"}]`, `Record 1: Invalid score 120`},
+		{FormatJSON, `[{"name":"","sex":"男","scope":90,"grade":"一班"}]`, `Record 1: Name is required`},
+		{"xml", ``, `Unknown format "xml"`},
+	}
+	for _, c := range cases {
+		_, err := ReadStudents(strings.NewReader(c.input), c.format)
+		if err == nil || !strings.HasPrefix(err.Error(), c.err) {
+			t.Fatalf("Wrong error for %q:%v", c.input, err)
+		}
+	}
+}
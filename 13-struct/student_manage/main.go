@@ -1,14 +1,12 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
-)
-
-var (
-	StudentManage = &Manager{}
-	choose        int
-	name          string
+	"strconv"
+	"strings"
 )
 
 func showMenu() {
@@ -20,42 +18,100 @@ func showMenu() {
 	fmt.Println("5.退出")
 }
 
-func ScanStudent() *Student {
+// 提示并读取一行，校验失败时重新输入，输入结束时返回 false
+func prompt(reader *bufio.Reader, label string, check func(string) error) (string, bool) {
+	for {
+		if label != "" {
+			fmt.Println(label)
+		}
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if err != nil && line == "" {
+			return "", false
+		}
+		if check == nil {
+			return line, true
+		}
+		if checkErr := check(line); checkErr != nil {
+			fmt.Println(checkErr)
+			if err != nil {
+				return "", false
+			}
+			continue
+		}
+		return line, true
+	}
+}
+
+func ScanStudent(reader *bufio.Reader) (*Student, bool) {
 	var (
-		name  string
-		sex   string
-		scope int
-		grade string
+		stu = &Student{}
+		ok  bool
 	)
-	fmt.Println("请输入学生姓名")
-	_, _ = fmt.Scanf("%s\n", &name)
-	fmt.Println("请输入学生性别")
-	_, _ = fmt.Scanf("%s\n", &sex)
-	fmt.Println("请输入学生分数")
-	_, _ = fmt.Scanf("%d\n", &scope)
-	fmt.Println("请输入学生班级")
-	_, _ = fmt.Scanf("%s\n", &grade)
-
-	return NewStudent(name, sex, scope, grade)
+	if stu.Name, ok = prompt(reader, "请输入学生姓名", ValidateName); !ok {
+		return nil, false
+	}
+	if stu.Sex, ok = prompt(reader, "请输入学生性别", ValidateSex); !ok {
+		return nil, false
+	}
+	if _, ok = prompt(reader, "请输入学生分数", func(s string) (err error) {
+		stu.Scope, err = ParseScore(s)
+		return err
+	}); !ok {
+		return nil, false
+	}
+	if stu.Grade, ok = prompt(reader, "请输入学生班级", func(s string) error {
+		if s == "" {
+			return fmt.Errorf("Grade is required")
+		}
+		return nil
+	}); !ok {
+		return nil, false
+	}
+	return stu, true
 }
 
-func main() {
+// 交互式菜单，输入结束或选择退出时返回
+func runMenu(manager *Manager, in io.Reader) {
+	reader := bufio.NewReader(in)
 	for {
 		showMenu()
-		_, _ = fmt.Scanf("%d\n", &choose)
+		line, ok := prompt(reader, "", nil)
+		if !ok {
+			return
+		}
+		choose, err := strconv.Atoi(line)
+		if err != nil {
+			fmt.Println("无效的选项！")
+			continue
+		}
 		switch choose {
 		case 1:
-			StudentManage.ShowStudent()
+			manager.ShowStudent()
 		case 2:
-			StudentManage.AddStudent(ScanStudent())
+			if stu, ok := ScanStudent(reader); ok {
+				manager.AddStudent(stu)
+			}
 		case 3:
-			StudentManage.EditStudent(ScanStudent())
+			if stu, ok := ScanStudent(reader); ok {
+				manager.EditStudent(stu)
+			}
 		case 4:
-			fmt.Println("请输入需要删除的学生")
-			_, _ = fmt.Scanf("%s\n", &name)
-			StudentManage.deleteStudent(name)
+			if name, ok := prompt(reader, "请输入需要删除的学生", ValidateName); ok {
+				manager.deleteStudent(name)
+			}
 		case 5:
-			os.Exit(0)
+			return
+		default:
+			fmt.Println("无效的选项！")
 		}
 	}
 }
+
+func main() {
+	if err := newApp().Run(os.Args); err != nil {
+		// 命令内的错误已由 cli 按退出码处理，这里只剩参数解析错误
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+}
@@ -5,52 +5,64 @@ import (
 )
 
 type Manager struct {
-	students []*Student
+	repo StudentRepository
+}
+
+func NewManager(repo StudentRepository) *Manager {
+	return &Manager{repo: repo}
 }
 
 // 查询
 func (m *Manager) ShowStudent() {
-	if len(m.students) == 0 {
+	students, err := m.repo.List()
+	if err != nil {
+		fmt.Printf("查询失败:%v\n", err)
+		return
+	}
+	if len(students) == 0 {
 		fmt.Println("暂无学生")
 		return
 	}
-	for i, v := range m.students {
+	for i, v := range students {
 		fmt.Printf("学生【%d】:%v \n", i, v)
 	}
 }
 
-// 添加
+// 添加，同名学生直接覆盖
 func (m *Manager) AddStudent(stu *Student) {
-	for i, v := range m.students {
-		if v.Name == stu.Name {
-			m.students[i] = stu
-			return
-		}
+	if err := m.repo.Save(stu); err != nil {
+		fmt.Printf("添加失败:%v\n", err)
+		return
 	}
-	m.students = append(m.students, stu)
 	fmt.Println("添加成功！")
 }
 
 // 修改
 func (m *Manager) EditStudent(stu *Student) {
-	for i, v := range m.students {
-		if v.Name == stu.Name {
-			m.students[i] = stu
-			fmt.Println("修改成功！")
-			return
-		}
-	}
-	fmt.Println("学生没有找到！")
+	if _, err := m.repo.Get(stu.Name); err != nil {
+		m.printError("修改失败", err)
+		return
+	}
+	if err := m.repo.Save(stu); err != nil {
+		fmt.Printf("修改失败:%v\n", err)
+		return
+	}
+	fmt.Println("修改成功！")
 }
 
 // 删除
 func (m *Manager) deleteStudent(name string) {
-	for i, v := range m.students {
-		if v.Name == name {
-			m.students = append(m.students[:i], m.students[i+1:]...)
-			fmt.Println("删除成功")
-			return
-		}
-	}
-	fmt.Println("学生没有找到！")
+	if err := m.repo.Delete(name); err != nil {
+		m.printError("删除失败", err)
+		return
+	}
+	fmt.Println("删除成功")
+}
+
+func (m *Manager) printError(action string, err error) {
+	if err == ErrStudentNotFound {
+		fmt.Println("学生没有找到！")
+		return
+	}
+	fmt.Printf("%s:%v\n", action, err)
 }
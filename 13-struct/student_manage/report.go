@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// html 报告格式
+const FormatHTML = "html"
+
+// 直方图条形的最大宽度
+const barWidth = 40
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": func(v float64) string { return fmt.Sprintf("%.1f%%", v*100) },
+	"fixed":   func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"width":   func(count, max int) int { return barLength(count, max, 100) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>学生成绩统计</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 10px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.bar { background: #4a90d9; height: 14px; }
+</style>
+</head>
+<body>
+<h1>学生成绩统计</h1>
+<h2>班级统计（及格线 {{.PassScore}}）</h2>
+<table>
+<tr><th>班级</th><th>人数</th><th>平均分</th><th>中位数</th><th>标准差</th><th>最低分</th><th>最高分</th><th>及格率</th></tr>
+{{range .Stats}}<tr><td>{{.Grade}}</td><td>{{.Count}}</td><td>{{fixed .Mean}}</td><td>{{fixed .Median}}</td><td>{{fixed .StdDev}}</td><td>{{.Min}}</td><td>{{.Max}}</td><td>{{percent .PassRate}}</td></tr>
+{{end}}</table>
+<h2>分数分布</h2>
+<table>
+<tr><th>分数段</th><th>人数</th><th></th></tr>
+{{$max := .MaxBucket}}{{range .Histogram}}<tr><td>{{.Label}}</td><td>{{.Count}}</td><td style="width:300px;text-align:left"><div class="bar" style="width:{{width .Count $max}}%"></div></td></tr>
+{{end}}</table>
+<h2>排名</h2>
+<table>
+<tr><th>姓名</th><th>名次</th><th>班级</th><th>班级名次</th><th>分数</th></tr>
+{{range .Ranking}}<tr><td>{{.Name}}</td><td>{{.Rank}}</td><td>{{.Grade}}</td><td>{{.GradeRank}}</td><td>{{.Score}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// 全部与各班级的统计
+func (r *Report) stats() []ScoreStats {
+	return append([]ScoreStats{r.Overall}, r.Grades...)
+}
+
+// 直方图中的最大人数
+func (r *Report) maxBucket() int {
+	max := 0
+	for _, bucket := range r.Histogram {
+		if bucket.Count > max {
+			max = bucket.Count
+		}
+	}
+	return max
+}
+
+func barLength(count, max, width int) int {
+	if max == 0 {
+		return 0
+	}
+	return count * width / max
+}
+
+// 按格式输出报告
+func WriteReport(w io.Writer, report *Report, format string) error {
+	switch format {
+	case FormatTable:
+		return writeReportTable(w, report)
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case FormatHTML:
+		return reportTemplate.Execute(w, struct {
+			*Report
+			Stats     []ScoreStats
+			MaxBucket int
+		}{report, report.stats(), report.maxBucket()})
+	}
+	return fmt.Errorf("Unknown format %q, must be one of table, json, html", format)
+}
+
+func writeReportTable(w io.Writer, report *Report) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "Grade statistics (pass score %d)\n", report.PassScore)
+	fmt.Fprintln(tw, "GRADE\tCOUNT\tMEAN\tMEDIAN\tSTDDEV\tMIN\tMAX\tPASS RATE\t")
+	for _, stats := range report.stats() {
+		fmt.Fprintf(tw, "%s\t%d\t%.2f\t%.2f\t%.2f\t%d\t%d\t%.1f%%\t\n",
+			stats.Grade, stats.Count, stats.Mean, stats.Median, stats.StdDev, stats.Min, stats.Max, stats.PassRate*100)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "\nScore histogram")
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	max := report.maxBucket()
+	for _, bucket := range report.Histogram {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", bucket.Label, bucket.Count, strings.Repeat("#", barLength(bucket.Count, max, barWidth)))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "\nRanking")
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RANK\tNAME\tGRADE\tGRADE RANK\tSCORE")
+	for _, rank := range report.Ranking {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%d\n", rank.Rank, rank.Name, rank.Grade, rank.GradeRank, rank.Score)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// 学生不存在
+var ErrStudentNotFound = errors.New("Student not found")
+
+// 学生存储接口，按姓名唯一标识学生
+type StudentRepository interface {
+	// 按添加顺序返回所有学生
+	List() ([]*Student, error)
+	// 查询学生，不存在时返回 ErrStudentNotFound
+	Get(name string) (*Student, error)
+	// 保存学生，已存在时覆盖
+	Save(stu *Student) error
+	// 批量保存学生，已存在时覆盖，全部成功或全部失败
+	SaveAll(students []*Student) error
+	// 删除学生，不存在时返回 ErrStudentNotFound
+	Delete(name string) error
+}
+
+// 内存存储，退出后数据丢失
+type MemoryRepository struct {
+	mu       sync.RWMutex
+	students []*Student
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{}
+}
+
+func (r *MemoryRepository) List() ([]*Student, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return copyStudents(r.students), nil
+}
+
+func (r *MemoryRepository) Get(name string) (*Student, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return findStudent(r.students, name)
+}
+
+func (r *MemoryRepository) Save(stu *Student) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.students = saveStudent(r.students, stu)
+	return nil
+}
+
+func (r *MemoryRepository) SaveAll(students []*Student) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, stu := range students {
+		r.students = saveStudent(r.students, stu)
+	}
+	return nil
+}
+
+func (r *MemoryRepository) Delete(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	students, err := deleteStudent(r.students, name)
+	if err != nil {
+		return err
+	}
+	r.students = students
+	return nil
+}
+
+/*
+JSON 文件存储：
+每次操作前加文件锁并重新读取文件，修改后写入临时文件再重命名，
+多个进程同时读写同一个文件时不会互相覆盖，也不会留下写了一半的文件
+*/
+type JSONRepository struct {
+	mu   sync.Mutex
+	path string
+	lock *fileLock
+}
+
+// 打开 JSON 文件存储，文件不存在时在首次保存时创建
+func NewJSONRepository(path string) (*JSONRepository, error) {
+	lock, err := newFileLock(path + ".lock")
+	if err != nil {
+		return nil, err
+	}
+	r := &JSONRepository{path: path, lock: lock}
+	// 启动时读取一次，尽早发现文件格式错误
+	if err = r.read(func(students []*Student) error { return nil }); err != nil {
+		lock.close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// 关闭文件锁
+func (r *JSONRepository) Close() error {
+	return r.lock.close()
+}
+
+func (r *JSONRepository) List() ([]*Student, error) {
+	var students []*Student
+	err := r.read(func(loaded []*Student) error {
+		students = loaded
+		return nil
+	})
+	return students, err
+}
+
+func (r *JSONRepository) Get(name string) (*Student, error) {
+	var stu *Student
+	err := r.read(func(students []*Student) (err error) {
+		stu, err = findStudent(students, name)
+		return err
+	})
+	return stu, err
+}
+
+func (r *JSONRepository) Save(stu *Student) error {
+	return r.write(func(students []*Student) ([]*Student, error) {
+		return saveStudent(students, stu), nil
+	})
+}
+
+// 一次加锁并写回，避免中途失败时只导入一部分
+func (r *JSONRepository) SaveAll(students []*Student) error {
+	return r.write(func(loaded []*Student) ([]*Student, error) {
+		for _, stu := range students {
+			loaded = saveStudent(loaded, stu)
+		}
+		return loaded, nil
+	})
+}
+
+func (r *JSONRepository) Delete(name string) error {
+	return r.write(func(students []*Student) ([]*Student, error) {
+		return deleteStudent(students, name)
+	})
+}
+
+// 加锁读取
+func (r *JSONRepository) read(fn func(students []*Student) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.lock.lock(); err != nil {
+		return err
+	}
+	defer r.lock.unlock()
+	students, err := r.load()
+	if err != nil {
+		return err
+	}
+	return fn(students)
+}
+
+// 加锁读取、修改并写回
+func (r *JSONRepository) write(fn func(students []*Student) ([]*Student, error)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.lock.lock(); err != nil {
+		return err
+	}
+	defer r.lock.unlock()
+	students, err := r.load()
+	if err != nil {
+		return err
+	}
+	if students, err = fn(students); err != nil {
+		return err
+	}
+	return r.store(students)
+}
+
+// 读取文件，文件不存在时返回空列表
+func (r *JSONRepository) load() ([]*Student, error) {
+	data, err := ioutil.ReadFile(r.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var students []*Student
+	if len(data) == 0 {
+		return students, nil
+	}
+	if err = json.Unmarshal(data, &students); err != nil {
+		return nil, fmt.Errorf("Failed to load students from %s, err:%v", r.path, err)
+	}
+	return students, nil
+}
+
+// 写入临时文件后重命名替换
+func (r *JSONRepository) store(students []*Student) error {
+	if students == nil {
+		students = []*Student{}
+	}
+	data, err := json.MarshalIndent(students, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(r.path), filepath.Base(r.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), r.path)
+}
+
+// 复制学生列表，避免调用方修改存储中的数据
+func copyStudents(students []*Student) []*Student {
+	copied := make([]*Student, len(students))
+	for i, stu := range students {
+		s := *stu
+		copied[i] = &s
+	}
+	return copied
+}
+
+func findStudent(students []*Student, name string) (*Student, error) {
+	for _, stu := range students {
+		if stu.Name == name {
+			s := *stu
+			return &s, nil
+		}
+	}
+	return nil, ErrStudentNotFound
+}
+
+func saveStudent(students []*Student, stu *Student) []*Student {
+	s := *stu
+	for i, v := range students {
+		if v.Name == stu.Name {
+			students[i] = &s
+			return students
+		}
+	}
+	return append(students, &s)
+}
+
+func deleteStudent(students []*Student, name string) ([]*Student, error) {
+	for i, v := range students {
+		if v.Name == name {
+			return append(students[:i], students[i+1:]...), nil
+		}
+	}
+	return nil, ErrStudentNotFound
+}
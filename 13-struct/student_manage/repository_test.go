@@ -0,0 +1,111 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func testRepository(t *testing.T, repo StudentRepository) {
+	_ = repo.Save(NewStudent("张三", "男", 90, "一班"))
+	_ = repo.Save(NewStudent("李四", "女", 80, "二班"))
+	// 同名覆盖
+	_ = repo.Save(NewStudent("张三", "男", 95, "一班"))
+
+	students, err := repo.List()
+	if err != nil || len(students) != 2 {
+		t.Fatalf("Wrong students:%v, err:%v", students, err)
+	}
+	if students[0].Name != "张三" || students[0].Scope != 95 {
+		t.Fatalf("Wrong student:%+v", students[0])
+	}
+	// 返回值是副本
+	students[0].Scope = 0
+	if stu, _ := repo.Get("张三"); stu.Scope != 95 {
+		t.Fatalf("Repository modified by caller:%+v", stu)
+	}
+
+	if err = repo.SaveAll([]*Student{
+		NewStudent("王五", "男", 70, "三班"),
+		NewStudent("李四", "女", 85, "二班"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if students, _ = repo.List(); len(students) != 3 || students[1].Scope != 85 || students[2].Name != "王五" {
+		t.Fatalf("Wrong students after SaveAll:%v", students)
+	}
+
+	if err = repo.Delete("张三"); err != nil {
+		t.Fatal(err)
+	}
+	if err = repo.Delete("张三"); err != ErrStudentNotFound {
+		t.Fatalf("Wrong error:%v", err)
+	}
+	if _, err = repo.Get("张三"); err != ErrStudentNotFound {
+		t.Fatalf("Wrong error:%v", err)
+	}
+}
+
+func TestMemoryRepository(t *testing.T) {
+	testRepository(t, NewMemoryRepository())
+}
+
+func TestJSONRepository(t *testing.T) {
+	dir, err := ioutil.TempDir("", "student")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "students.json")
+
+	repo, err := NewJSONRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testRepository(t, repo)
+	_ = repo.Close()
+
+	// 重新打开后数据仍在
+	repo, err = NewJSONRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	if stu, err := repo.Get("李四"); err != nil || stu.Grade != "二班" {
+		t.Fatalf("Wrong student:%+v, err:%v", stu, err)
+	}
+
+	// 两个实例并发写入同一文件不丢数据
+	other, err := NewJSONRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		r := StudentRepository(repo)
+		if i%2 == 1 {
+			r = other
+		}
+		go func(r StudentRepository, i int) {
+			defer wg.Done()
+			if err := r.Save(NewStudent(string(rune('a'+i)), "男", i, "三班")); err != nil {
+				t.Error(err)
+			}
+		}(r, i)
+	}
+	wg.Wait()
+	if students, _ := other.List(); len(students) != 22 {
+		t.Fatalf("Wrong count:%d", len(students))
+	}
+
+	if err = ioutil.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewJSONRepository(path); err == nil {
+		t.Fatal("Expected error for corrupted file")
+	}
+}
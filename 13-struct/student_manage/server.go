@@ -0,0 +1,268 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 默认每页数量，最大 100
+const DefaultPageSize = 20
+
+// 接口中的学生，分数字段使用 score
+type studentJSON struct {
+	Name  string `json:"name"`
+	Sex   string `json:"sex"`
+	Score int    `json:"score"`
+	Grade string `json:"grade"`
+}
+
+// 修改学生的请求体
+type studentBody struct {
+	Sex   string `json:"sex" binding:"required,oneof=男 女 male female"`
+	Score *int   `json:"score" binding:"required,min=0,max=100"`
+	Grade string `json:"grade" binding:"required"`
+}
+
+// 新增学生的请求体
+type createBody struct {
+	Name string `json:"name" binding:"required"`
+	studentBody
+}
+
+// 列表查询参数，sort 为字段名，加 - 前缀表示倒序
+type listQuery struct {
+	Grade    string `form:"grade"`
+	MinScore *int   `form:"min_score" binding:"omitempty,min=0,max=100"`
+	MaxScore *int   `form:"max_score" binding:"omitempty,min=0,max=100"`
+	Sort     string `form:"sort" binding:"omitempty,oneof=name -name score -score grade -grade"`
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+func toJSON(stu *Student) studentJSON {
+	return studentJSON{Name: stu.Name, Sex: stu.Sex, Score: stu.Scope, Grade: stu.Grade}
+}
+
+func (b studentBody) student(name string) *Student {
+	return NewStudent(name, b.Sex, *b.Score, b.Grade)
+}
+
+// 学生管理 http 服务
+type Server struct {
+	repo StudentRepository
+}
+
+func NewServer(repo StudentRepository) *Server {
+	return &Server{repo: repo}
+}
+
+// 注册路由
+func (s *Server) Router() *gin.Engine {
+	router := gin.Default()
+	// 按原始路径匹配，姓名中转义的 / 不会被当作路径分隔符
+	router.UseRawPath = true
+	students := router.Group("/students")
+	{
+		students.GET("", s.list)
+		students.POST("", s.create)
+		students.GET("/:id", s.get)
+		students.PUT("/:id", s.update)
+		students.DELETE("/:id", s.delete)
+	}
+	return router
+}
+
+// 出错时 code 为 -1
+func replyError(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"code":    -1,
+		"message": message,
+	})
+}
+
+// 成功时 code 为 200，http 状态码可能是 201 等
+func reply(c *gin.Context, status int, data interface{}) {
+	c.JSON(status, gin.H{
+		"code":    http.StatusOK,
+		"message": "ok",
+		"data":    data,
+	})
+}
+
+// 存储错误转为 http 状态码
+func replyRepoError(c *gin.Context, err error) {
+	if err == ErrStudentNotFound {
+		replyError(c, http.StatusNotFound, "student "+c.Param("id")+" not found")
+		return
+	}
+	replyError(c, http.StatusInternalServerError, err.Error())
+}
+
+// 列表，支持按班级、分数范围过滤，排序与分页
+func (s *Server) list(c *gin.Context) {
+	var query listQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		replyError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if query.MinScore != nil && query.MaxScore != nil && *query.MinScore > *query.MaxScore {
+		replyError(c, http.StatusBadRequest, "min_score must not be greater than max_score")
+		return
+	}
+	students, err := s.repo.List()
+	if err != nil {
+		replyRepoError(c, err)
+		return
+	}
+	students = filterStudents(students, query)
+	sortStudents(students, query.Sort)
+
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = DefaultPageSize
+	}
+	total := len(students)
+	// 先比较页数再相乘，避免超大页码溢出
+	start := total
+	if query.Page-1 <= total/query.PageSize {
+		if start = (query.Page - 1) * query.PageSize; start > total {
+			start = total
+		}
+	}
+	end := start + query.PageSize
+	if end > total {
+		end = total
+	}
+	data := make([]studentJSON, 0, end-start)
+	for _, stu := range students[start:end] {
+		data = append(data, toJSON(stu))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":      http.StatusOK,
+		"message":   "ok",
+		"data":      data,
+		"total":     total,
+		"page":      query.Page,
+		"page_size": query.PageSize,
+	})
+}
+
+func (s *Server) create(c *gin.Context) {
+	var body createBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		replyError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	stu := body.student(strings.TrimSpace(body.Name))
+	if err := stu.Validate(); err != nil {
+		replyError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := s.repo.Get(stu.Name); err == nil {
+		replyError(c, http.StatusConflict, "student "+stu.Name+" already exists")
+		return
+	} else if err != ErrStudentNotFound {
+		replyRepoError(c, err)
+		return
+	}
+	if err := s.repo.Save(stu); err != nil {
+		replyRepoError(c, err)
+		return
+	}
+	c.Header("Location", "/students/"+url.PathEscape(stu.Name))
+	reply(c, http.StatusCreated, toJSON(stu))
+}
+
+func (s *Server) get(c *gin.Context) {
+	stu, err := s.repo.Get(c.Param("id"))
+	if err != nil {
+		replyRepoError(c, err)
+		return
+	}
+	reply(c, http.StatusOK, toJSON(stu))
+}
+
+// 整体替换学生信息，姓名不可修改
+func (s *Server) update(c *gin.Context) {
+	var body studentBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		replyError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := s.repo.Get(c.Param("id")); err != nil {
+		replyRepoError(c, err)
+		return
+	}
+	stu := body.student(c.Param("id"))
+	if err := stu.Validate(); err != nil {
+		replyError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.repo.Save(stu); err != nil {
+		replyRepoError(c, err)
+		return
+	}
+	reply(c, http.StatusOK, toJSON(stu))
+}
+
+func (s *Server) delete(c *gin.Context) {
+	if err := s.repo.Delete(c.Param("id")); err != nil {
+		replyRepoError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "ok",
+	})
+}
+
+func filterStudents(students []*Student, query listQuery) []*Student {
+	filtered := students[:0]
+	for _, stu := range students {
+		if query.Grade != "" && stu.Grade != query.Grade {
+			continue
+		}
+		if query.MinScore != nil && stu.Scope < *query.MinScore {
+			continue
+		}
+		if query.MaxScore != nil && stu.Scope > *query.MaxScore {
+			continue
+		}
+		filtered = append(filtered, stu)
+	}
+	return filtered
+}
+
+// 按字段排序，相同时按姓名升序，未指定时保持添加顺序
+func sortStudents(students []*Student, key string) {
+	if key == "" {
+		return
+	}
+	desc := strings.HasPrefix(key, "-")
+	key = strings.TrimPrefix(key, "-")
+	sort.SliceStable(students, func(i, j int) bool {
+		a, b := students[i], students[j]
+		var cmp int
+		switch key {
+		case "name":
+			cmp = strings.Compare(a.Name, b.Name)
+		case "score":
+			cmp = a.Scope - b.Scope
+		case "grade":
+			cmp = strings.Compare(a.Grade, b.Grade)
+		}
+		if desc {
+			cmp = -cmp
+		}
+		if cmp != 0 {
+			return cmp < 0
+		}
+		return a.Name < b.Name
+	})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type response struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+	Total   int             `json:"total"`
+}
+
+func request(t *testing.T, router http.Handler, method, path, body string) (int, response) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var resp response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s %s: %v, body:%s", method, path, err, w.Body.String())
+	}
+	return w.Code, resp
+}
+
+func TestServer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := NewServer(NewMemoryRepository()).Router()
+
+	cases := []struct {
+		method, path, body string
+		status             int
+	}{
+		{"POST", "/students", `{"name":"张三","sex":"男","score":90,"grade":"一班"}`, http.StatusCreated},
+		{"POST", "/students", `{"name":"李四","sex":"女","score":0,"grade":"二班"}`, http.StatusCreated},
+		{"POST", "/students", `{"name":"王五","sex":"男","score":75,"grade":"一班"}`, http.StatusCreated},
+		{"POST", "/students", `{"name":"张三","sex":"男","score":90,"grade":"一班"}`, http.StatusConflict},
+		{"POST", "/students", `{"name":"赵六","sex":"x","score":90,"grade":"一班"}`, http.StatusBadRequest},
+		{"POST", "/students", `{"name":"赵六","sex":"男","score":101,"grade":"一班"}`, http.StatusBadRequest},
+		{"POST", "/students", `{"name":"赵六","sex":"男","grade":"一班"}`, http.StatusBadRequest},
+		{"POST", "/students", `{`, http.StatusBadRequest},
+		{"GET", "/students/" + url.PathEscape("张三"), ``, http.StatusOK},
+		{"GET", "/students/nobody", ``, http.StatusNotFound},
+		{"PUT", "/students/" + url.PathEscape("王五"), `{"sex":"男","score":85,"grade":"一班"}`, http.StatusOK},
+		{"PUT", "/students/nobody", `{"sex":"男","score":85,"grade":"一班"}`, http.StatusNotFound},
+		{"GET", "/students?min_score=50&max_score=10", ``, http.StatusBadRequest},
+		{"GET", "/students?sort=age", ``, http.StatusBadRequest},
+		{"GET", "/students?page=0&page_size=1000", ``, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		status, resp := request(t, router, c.method, c.path, c.body)
+		if status != c.status {
+			t.Fatalf("%s %s: wrong status %d, resp:%+v", c.method, c.path, status, resp)
+		}
+		if status < 400 && resp.Code != http.StatusOK {
+			t.Fatalf("%s %s: wrong code %+v", c.method, c.path, resp)
+		}
+		if status >= 400 && (resp.Code != -1 || resp.Message == "") {
+			t.Fatalf("%s %s: wrong error %+v", c.method, c.path, resp)
+		}
+	}
+
+	_, resp := request(t, router, "GET", "/students?grade="+url.QueryEscape("一班")+"&min_score=80&sort=-score", ``)
+	var students []studentJSON
+	_ = json.Unmarshal(resp.Data, &students)
+	if resp.Total != 2 || len(students) != 2 || students[0].Name != "张三" || students[1].Score != 85 {
+		t.Fatalf("Wrong list:%+v", students)
+	}
+
+	_, resp = request(t, router, "GET", "/students?sort=score&page=2&page_size=2", ``)
+	students = nil
+	_ = json.Unmarshal(resp.Data, &students)
+	if resp.Total != 3 || len(students) != 1 || students[0].Name != "张三" {
+		t.Fatalf("Wrong page:%+v", students)
+	}
+
+	// 超大页码返回空页而不是溢出
+	_, resp = request(t, router, "GET", "/students?page=100000000000000000&page_size=100", ``)
+	students = nil
+	_ = json.Unmarshal(resp.Data, &students)
+	if resp.Code != http.StatusOK || resp.Total != 3 || len(students) != 0 {
+		t.Fatalf("Wrong page:%+v", resp)
+	}
+
+	req := httptest.NewRequest("POST", "/students", strings.NewReader(`{"name":"a/b?c","sex":"男","score":60,"grade":"一班"}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if location := w.Header().Get("Location"); location != "/students/a%2Fb%3Fc" {
+		t.Fatalf("Wrong location:%s", location)
+	}
+	if status, _ := request(t, router, "GET", w.Header().Get("Location"), ``); status != http.StatusOK {
+		t.Fatalf("Wrong status:%d", status)
+	}
+
+	if status, _ := request(t, router, "DELETE", "/students/"+url.PathEscape("张三"), ``); status != http.StatusOK {
+		t.Fatalf("Wrong status:%d", status)
+	}
+	if status, _ := request(t, router, "DELETE", "/students/"+url.PathEscape("张三"), ``); status != http.StatusNotFound {
+		t.Fatalf("Wrong status:%d", status)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// 默认及格线
+const DefaultPassScore = 60
+
+// 默认直方图分段的下界
+var DefaultBuckets = []int{60, 70, 80, 90}
+
+// 汇总统计中的全部学生
+const AllGrades = "全部"
+
+// 分数统计
+type ScoreStats struct {
+	Grade    string  `json:"grade"`
+	Count    int     `json:"count"`
+	Mean     float64 `json:"mean"`
+	Median   float64 `json:"median"`
+	StdDev   float64 `json:"std_dev"`
+	Min      int     `json:"min"`
+	Max      int     `json:"max"`
+	PassRate float64 `json:"pass_rate"`
+}
+
+// 直方图分段，包含 Min 与 Max
+type Bucket struct {
+	Label string `json:"label"`
+	Min   int    `json:"min"`
+	Max   int    `json:"max"`
+	Count int    `json:"count"`
+}
+
+// 排名，同分同名次，下一名次跳过并列人数（1、2、2、4）
+type Rank struct {
+	Rank      int    `json:"rank"`
+	GradeRank int    `json:"grade_rank"`
+	Name      string `json:"name"`
+	Grade     string `json:"grade"`
+	Score     int    `json:"score"`
+}
+
+// 统计报告
+type Report struct {
+	PassScore int          `json:"pass_score"`
+	Overall   ScoreStats   `json:"overall"`
+	Grades    []ScoreStats `json:"grades"`
+	Histogram []Bucket     `json:"histogram"`
+	Ranking   []Rank       `json:"ranking"`
+}
+
+// 报告参数
+type ReportOptions struct {
+	// 及格线，0 表示全部及格，一般传 DefaultPassScore
+	PassScore int
+	// 直方图分段的下界，默认 DefaultBuckets
+	Buckets []int
+}
+
+// 生成统计报告，班级按名称排序
+func NewReport(students []*Student, opts ReportOptions) (*Report, error) {
+	if opts.Buckets == nil {
+		opts.Buckets = DefaultBuckets
+	}
+	histogram, err := newHistogram(opts.Buckets)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{
+		PassScore: opts.PassScore,
+		Overall:   scoreStats(AllGrades, students, opts.PassScore),
+		Histogram: histogram,
+		Ranking:   ranking(students),
+	}
+
+	grades := make(map[string][]*Student)
+	var names []string
+	for _, stu := range students {
+		if _, ok := grades[stu.Grade]; !ok {
+			names = append(names, stu.Grade)
+		}
+		grades[stu.Grade] = append(grades[stu.Grade], stu)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		report.Grades = append(report.Grades, scoreStats(name, grades[name], opts.PassScore))
+	}
+
+	for _, stu := range students {
+		for i := len(report.Histogram) - 1; i >= 0; i-- {
+			if stu.Scope >= report.Histogram[i].Min {
+				report.Histogram[i].Count++
+				break
+			}
+		}
+	}
+	return report, nil
+}
+
+func scoreStats(grade string, students []*Student, passScore int) ScoreStats {
+	stats := ScoreStats{Grade: grade, Count: len(students)}
+	if len(students) == 0 {
+		return stats
+	}
+	scores := make([]int, len(students))
+	sum, passed := 0, 0
+	for i, stu := range students {
+		scores[i] = stu.Scope
+		sum += stu.Scope
+		if stu.Scope >= passScore {
+			passed++
+		}
+	}
+	sort.Ints(scores)
+	n := float64(len(scores))
+	stats.Min, stats.Max = scores[0], scores[len(scores)-1]
+	stats.Mean = float64(sum) / n
+	if mid := len(scores) / 2; len(scores)%2 == 1 {
+		stats.Median = float64(scores[mid])
+	} else {
+		stats.Median = float64(scores[mid-1]+scores[mid]) / 2
+	}
+	var variance float64
+	for _, score := range scores {
+		variance += (float64(score) - stats.Mean) * (float64(score) - stats.Mean)
+	}
+	stats.StdDev = math.Sqrt(variance / n)
+	stats.PassRate = float64(passed) / n
+	return stats
+}
+
+// 按下界生成分段，下界需在分数范围内且递增
+func newHistogram(bounds []int) ([]Bucket, error) {
+	lows := []int{MinScore}
+	for i, bound := range bounds {
+		if bound <= MinScore || bound > MaxScore {
+			return nil, fmt.Errorf("Invalid bucket %d, must be between %d and %d", bound, MinScore+1, MaxScore)
+		}
+		if i > 0 && bound <= bounds[i-1] {
+			return nil, fmt.Errorf("Invalid buckets %v, must be increasing", bounds)
+		}
+		lows = append(lows, bound)
+	}
+	buckets := make([]Bucket, len(lows))
+	for i, low := range lows {
+		high := MaxScore
+		if i+1 < len(lows) {
+			high = lows[i+1] - 1
+		}
+		buckets[i] = Bucket{Label: fmt.Sprintf("%d-%d", low, high), Min: low, Max: high}
+	}
+	return buckets, nil
+}
+
+// 按分数从高到低排名，同分按姓名排序
+func ranking(students []*Student) []Rank {
+	sorted := make([]*Student, len(students))
+	copy(sorted, students)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Scope != sorted[j].Scope {
+			return sorted[i].Scope > sorted[j].Scope
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	ranks := make([]Rank, len(sorted))
+	// 每个班级已排名人数与上一名的分数、名次
+	type gradeState struct{ count, score, rank int }
+	grades := make(map[string]*gradeState)
+	for i, stu := range sorted {
+		rank := i + 1
+		if i > 0 && stu.Scope == sorted[i-1].Scope {
+			rank = ranks[i-1].Rank
+		}
+		state, ok := grades[stu.Grade]
+		if !ok {
+			state = &gradeState{}
+			grades[stu.Grade] = state
+		}
+		state.count++
+		if state.count == 1 || stu.Scope != state.score {
+			state.rank = state.count
+		}
+		state.score = stu.Scope
+		ranks[i] = Rank{Rank: rank, GradeRank: state.rank, Name: stu.Name, Grade: stu.Grade, Score: stu.Scope}
+	}
+	return ranks
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func sampleStudents() []*Student {
+	return []*Student{
+		NewStudent("张三", "男", 90, "一班"),
+		NewStudent("李四", "女", 58, "一班"),
+		NewStudent("王五", "男", 90, "二班"),
+		NewStudent("赵六", "女", 75, "二班"),
+		NewStudent("钱七", "男", 75, "二班"),
+		NewStudent("孙八", "女", 100, "一班"),
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	report, err := NewReport(sampleStudents(), ReportOptions{PassScore: DefaultPassScore})
+	if err != nil {
+		t.Fatal(err)
+	}
+	overall := report.Overall
+	if overall.Count != 6 || overall.Min != 58 || overall.Max != 100 || overall.Median != 82.5 {
+		t.Fatalf("Wrong overall:%+v", overall)
+	}
+	if math.Abs(overall.Mean-81.333) > 0.001 || math.Abs(overall.StdDev-13.683) > 0.001 || math.Abs(overall.PassRate-5.0/6) > 0.001 {
+		t.Fatalf("Wrong overall:%+v", overall)
+	}
+	if len(report.Grades) != 2 || report.Grades[0].Grade != "一班" || report.Grades[1].Median != 75 || report.Grades[1].PassRate != 1 {
+		t.Fatalf("Wrong grades:%+v", report.Grades)
+	}
+
+	counts := []int{1, 0, 2, 0, 3}
+	for i, bucket := range report.Histogram {
+		if bucket.Count != counts[i] {
+			t.Fatalf("Wrong histogram:%+v", report.Histogram)
+		}
+	}
+	if report.Histogram[4].Label != "90-100" {
+		t.Fatalf("Wrong label:%s", report.Histogram[4].Label)
+	}
+
+	// 同分同名次，下一名次跳过
+	ranks := [][2]int{{1, 1}, {2, 2}, {2, 1}, {4, 2}, {4, 2}, {6, 3}}
+	for i, rank := range report.Ranking {
+		if rank.Rank != ranks[i][0] || rank.GradeRank != ranks[i][1] {
+			t.Fatalf("Wrong rank at %d:%+v", i, rank)
+		}
+	}
+
+	// 及格线为 0 时不会被替换为默认值
+	if report, err = NewReport(sampleStudents(), ReportOptions{PassScore: 0}); err != nil || report.PassScore != 0 || report.Overall.PassRate != 1 {
+		t.Fatalf("Wrong report for pass score 0:%+v, err:%v", report, err)
+	}
+
+	for _, buckets := range [][]int{{70, 60}, {0}, {101}} {
+		if _, err = NewReport(nil, ReportOptions{Buckets: buckets}); err == nil {
+			t.Fatalf("Expected error for buckets %v", buckets)
+		}
+	}
+	if report, err = NewReport(nil, ReportOptions{Buckets: []int{}}); err != nil || len(report.Histogram) != 1 || report.Overall.Count != 0 {
+		t.Fatalf("Wrong empty report:%+v, err:%v", report, err)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	report, err := NewReport(sampleStudents(), ReportOptions{PassScore: 80, Buckets: []int{50, 75}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for format, want := range map[string]string{
+		FormatTable: "4     赵六",
+		FormatJSON:  `"pass_score": 80`,
+		FormatHTML:  "<td>75-100</td><td>5</td>",
+	} {
+		var buf bytes.Buffer
+		if err = WriteReport(&buf, report, format); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("%s report missing %q:\n%s", format, want, buf.String())
+		}
+	}
+	if err = WriteReport(&bytes.Buffer{}, report, "xml"); err == nil {
+		t.Fatal("Expected error for unknown format")
+	}
+}
@@ -1,5 +1,20 @@
 package main
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 分数范围
+const (
+	MinScore = 0
+	MaxScore = 100
+)
+
+// 允许的性别
+var Sexes = []string{"男", "女", "male", "female"}
+
 type Student struct {
 	Name  string `json:"name"`
 	Sex   string `json:"sex"`
@@ -15,3 +30,48 @@ func NewStudent(name string, sex string, scope int, grade string) *Student {
 		Grade: grade,
 	}
 }
+
+// 校验学生信息
+func (s *Student) Validate() error {
+	if err := ValidateName(s.Name); err != nil {
+		return err
+	}
+	if err := ValidateSex(s.Sex); err != nil {
+		return err
+	}
+	if s.Scope < MinScore || s.Scope > MaxScore {
+		return fmt.Errorf("Invalid score %d, must be between %d and %d", s.Scope, MinScore, MaxScore)
+	}
+	if strings.TrimSpace(s.Grade) == "" {
+		return fmt.Errorf("Grade is required")
+	}
+	return nil
+}
+
+func ValidateName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("Name is required")
+	}
+	return nil
+}
+
+func ValidateSex(sex string) error {
+	for _, v := range Sexes {
+		if sex == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("Invalid sex %q, must be one of %s", sex, strings.Join(Sexes, ", "))
+}
+
+// 解析分数，非整数或超出范围时返回错误
+func ParseScore(s string) (int, error) {
+	score, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("Invalid score %q, must be an integer", s)
+	}
+	if score < MinScore || score > MaxScore {
+		return 0, fmt.Errorf("Invalid score %d, must be between %d and %d", score, MinScore, MaxScore)
+	}
+	return score, nil
+}
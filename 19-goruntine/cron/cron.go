@@ -0,0 +1,326 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/learning_golang/19-goruntine/workpool"
+)
+
+// 默认协程池大小
+const DefaultWorkers = 8
+
+// 上一次执行未结束时的处理方式
+type OverlapPolicy int
+
+const (
+	// 允许同时执行
+	OverlapAllow OverlapPolicy = iota
+	// 跳过本次执行
+	OverlapSkip
+)
+
+var (
+	// 任务名称重复
+	ErrDuplicate = errors.New("Cron job already exists")
+	// 任务不存在
+	ErrNotFound = errors.New("Cron job not found")
+)
+
+// 定时任务
+type Job struct {
+	Name string
+	// cron 表达式，见 Parse
+	Spec string
+	Task workpool.Task
+	// 上一次执行未结束时的处理方式，默认使用 Options.Overlap
+	Overlap *OverlapPolicy
+	// 在 0 ~ Jitter 之间随机延迟执行，避免大量任务同时触发，默认使用 Options.Jitter
+	Jitter time.Duration
+
+	schedule Schedule
+	next     time.Time
+	prev     time.Time
+	running  int32
+}
+
+// 任务状态
+type Entry struct {
+	Name string
+	Spec string
+	// 下一次触发时间
+	Next time.Time
+	// 上一次触发时间，未触发时为零值
+	Prev time.Time
+	// 正在执行的次数
+	Running int
+}
+
+// 调度器参数
+type Options struct {
+	// 执行任务的协程池，为空时创建 DefaultWorkers 大小的协程池，并在 Stop 时停止
+	Pool *workpool.Pool
+	// 表达式未指定 CRON_TZ 时使用的时区，默认本地时区
+	Location *time.Location
+	// 默认重叠处理方式
+	Overlap OverlapPolicy
+	// 默认随机延迟
+	Jitter time.Duration
+	// 因重叠跳过执行时的回调
+	OnSkip func(name string)
+	// 任务执行失败时的回调
+	OnError func(name string, err error)
+}
+
+/*
+定时任务调度器：
+单个协程按最近的触发时间等待，到期后将任务提交到协程池执行，
+添加或删除任务时唤醒重新计算等待时间
+*/
+type Scheduler struct {
+	options  Options
+	ownPool  bool
+	mu       sync.Mutex
+	jobs     map[string]*Job
+	wake     chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+	started  bool
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// 创建调度器
+func New(options Options) (*Scheduler, error) {
+	s := &Scheduler{
+		jobs: make(map[string]*Job),
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	if options.Location == nil {
+		options.Location = time.Local
+	}
+	if options.Pool == nil {
+		pool, err := workpool.NewWithOptions(workpool.Options{Size: DefaultWorkers})
+		if err != nil {
+			return nil, err
+		}
+		options.Pool = pool
+		s.ownPool = true
+	}
+	s.options = options
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	return s, nil
+}
+
+// 添加定时任务
+func (s *Scheduler) Add(name string, spec string, task workpool.Task) error {
+	return s.AddJob(&Job{Name: name, Spec: spec, Task: task})
+}
+
+// 添加定时任务，可单独设置重叠处理方式与随机延迟
+func (s *Scheduler) AddJob(job *Job) error {
+	if job.Task == nil {
+		return errors.New("Task is nil")
+	}
+	schedule, err := ParseInLocation(job.Spec, s.options.Location)
+	if err != nil {
+		return err
+	}
+	job.schedule = schedule
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[job.Name]; ok {
+		return fmt.Errorf("%v:%s", ErrDuplicate, job.Name)
+	}
+	job.next = schedule.Next(time.Now())
+	s.jobs[job.Name] = job
+	s.notify()
+	return nil
+}
+
+// 删除定时任务，正在执行的任务不受影响
+func (s *Scheduler) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[name]; !ok {
+		return ErrNotFound
+	}
+	delete(s.jobs, name)
+	s.notify()
+	return nil
+}
+
+// 所有任务状态，按下一次触发时间排序
+func (s *Scheduler) Entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]Entry, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		entries = append(entries, Entry{
+			Name:    job.Name,
+			Spec:    job.Spec,
+			Next:    job.next,
+			Prev:    job.prev,
+			Running: int(atomic.LoadInt32(&job.running)),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Next.Before(entries[j].Next)
+	})
+	return entries
+}
+
+// 任务接下来 n 次触发时间，不含随机延迟
+func (s *Scheduler) NextRuns(name string, n int) ([]time.Time, error) {
+	s.mu.Lock()
+	job, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return NextTimes(job.schedule, time.Now(), n), nil
+}
+
+// 启动调度协程
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	go s.run()
+}
+
+// 停止调度，不再触发新的执行；自建的协程池等待正在执行的任务完成后停止
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() {
+		s.cancel()
+		s.mu.Lock()
+		started := s.started
+		s.mu.Unlock()
+		if started {
+			<-s.done
+		}
+		if s.ownPool {
+			s.options.Pool.StopWait()
+		}
+	})
+}
+
+// 唤醒调度协程，调用方持有锁
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// 调度循环
+func (s *Scheduler) run() {
+	defer close(s.done)
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if next, ok := s.earliest(); ok {
+			timer.Reset(time.Until(next))
+		} else {
+			timer.Reset(time.Hour)
+		}
+
+		select {
+		case now := <-timer.C:
+			s.fireDue(now)
+		case <-s.wake:
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// 最近的触发时间
+func (s *Scheduler) earliest() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var next time.Time
+	for _, job := range s.jobs {
+		if job.next.IsZero() {
+			continue
+		}
+		if next.IsZero() || job.next.Before(next) {
+			next = job.next
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// 触发所有到期的任务并计算下一次触发时间
+func (s *Scheduler) fireDue(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		if job.next.IsZero() || job.next.After(now) {
+			continue
+		}
+		job.prev = job.next
+		job.next = job.schedule.Next(now)
+		s.fire(job)
+	}
+}
+
+// 按重叠策略与随机延迟提交任务
+func (s *Scheduler) fire(job *Job) {
+	overlap := s.options.Overlap
+	if job.Overlap != nil {
+		overlap = *job.Overlap
+	}
+	if overlap == OverlapSkip && atomic.LoadInt32(&job.running) > 0 {
+		if s.options.OnSkip != nil {
+			go s.options.OnSkip(job.Name)
+		}
+		return
+	}
+	atomic.AddInt32(&job.running, 1)
+
+	jitter := job.Jitter
+	if jitter <= 0 {
+		jitter = s.options.Jitter
+	}
+	if jitter <= 0 {
+		go s.submit(job)
+		return
+	}
+	time.AfterFunc(time.Duration(rand.Int63n(int64(jitter))), func() {
+		s.submit(job)
+	})
+}
+
+// 提交到协程池并等待完成
+func (s *Scheduler) submit(job *Job) {
+	defer atomic.AddInt32(&job.running, -1)
+	if s.ctx.Err() != nil {
+		return
+	}
+	// 任务不随调度器停止而取消
+	submitted, err := s.options.Pool.Submit(context.Background(), job.Task)
+	if err == nil {
+		err = submitted.Wait()
+	}
+	if err != nil && s.ctx.Err() == nil && s.options.OnError != nil {
+		s.options.OnError(job.Name, err)
+	}
+}
@@ -0,0 +1,116 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	var (
+		count  int32
+		failed int32
+	)
+	scheduler, err := New(Options{
+		OnError: func(name string, err error) {
+			atomic.AddInt32(&failed, 1)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = scheduler.Add("count", "@every 20ms", func(ctx context.Context) error {
+		atomic.AddInt32(&count, 1)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = scheduler.Add("fail", "@every 20ms", func(ctx context.Context) error {
+		return errors.New("failed")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = scheduler.Add("count", "@daily", func(ctx context.Context) error { return nil }); err == nil {
+		t.Fatal("Duplicate job should fail")
+	}
+	scheduler.Start()
+	time.Sleep(150 * time.Millisecond)
+	if err = scheduler.Remove("fail"); err != nil {
+		t.Fatal(err)
+	}
+	scheduler.Stop()
+
+	if n := atomic.LoadInt32(&count); n < 3 {
+		t.Fatalf("Job should run several times, got:%d", n)
+	}
+	if n := atomic.LoadInt32(&failed); n < 3 {
+		t.Fatalf("OnError should be called, got:%d", n)
+	}
+	entries := scheduler.Entries()
+	if len(entries) != 1 || entries[0].Name != "count" || entries[0].Prev.IsZero() {
+		t.Fatalf("Wrong entries:%+v", entries)
+	}
+}
+
+func TestSchedulerOverlapSkip(t *testing.T) {
+	var (
+		runs    int32
+		skipped int32
+	)
+	scheduler, err := New(Options{
+		OnSkip: func(name string) {
+			atomic.AddInt32(&skipped, 1)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	skip := OverlapSkip
+	err = scheduler.AddJob(&Job{
+		Name:    "slow",
+		Spec:    "@every 10ms",
+		Overlap: &skip,
+		Task: func(ctx context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			time.Sleep(100 * time.Millisecond)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduler.Start()
+	time.Sleep(150 * time.Millisecond)
+	scheduler.Stop()
+	if n := atomic.LoadInt32(&runs); n > 2 {
+		t.Fatalf("Overlapping runs should be skipped, runs:%d", n)
+	}
+	if atomic.LoadInt32(&skipped) == 0 {
+		t.Fatal("OnSkip should be called")
+	}
+}
+
+func TestSchedulerNextRuns(t *testing.T) {
+	scheduler, err := New(Options{Location: time.UTC})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scheduler.Stop()
+	if err = scheduler.Add("hourly", "0 * * * *", func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	times, err := scheduler.NextRuns("hourly", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(times) != 3 || times[0].Minute() != 0 || times[1].Sub(times[0]) != time.Hour {
+		t.Fatalf("Wrong next runs:%v", times)
+	}
+	if _, err = scheduler.NextRuns("missing", 3); err != ErrNotFound {
+		t.Fatalf("Wrong error:%v", err)
+	}
+}
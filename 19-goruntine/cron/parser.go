@@ -0,0 +1,341 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 时间表，返回 t 之后的下一次执行时间，没有时返回零值
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// 字段取值范围
+type bounds struct {
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	seconds = bounds{0, 59, nil}
+	minutes = bounds{0, 59, nil}
+	hours   = bounds{0, 23, nil}
+	dom     = bounds{1, 31, nil}
+	months  = bounds{1, 12, map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 同样表示周日
+	dow = bounds{0, 7, map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// 预定义表达式
+var descriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// 字段为 * 或 ? 时设置的标记位，用于区分日与星期的匹配方式
+const starBit = 1 << 63
+
+/*
+解析 cron 表达式，时区为本地时区：
+5 个字段：分 时 日 月 星期；6 个字段：秒 分 时 日 月 星期；
+支持 * ? , - / 与月份、星期英文缩写，
+预定义表达式 @yearly @monthly @weekly @daily @hourly 与 @every 5m，
+以 CRON_TZ=Asia/Shanghai 开头时使用指定时区
+*/
+func Parse(spec string) (Schedule, error) {
+	return ParseInLocation(spec, time.Local)
+}
+
+// 按指定时区解析 cron 表达式
+func ParseInLocation(spec string, loc *time.Location) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		index := strings.IndexAny(spec, " \t")
+		if index == -1 {
+			return nil, fmt.Errorf("Invalid cron spec:%s", spec)
+		}
+		name := spec[strings.Index(spec, "=")+1 : index]
+		var err error
+		if loc, err = time.LoadLocation(name); err != nil {
+			return nil, fmt.Errorf("Invalid timezone:%s, err:%v", name, err)
+		}
+		spec = strings.TrimSpace(spec[index:])
+	}
+
+	if strings.HasPrefix(spec, "@every ") {
+		every, err := time.ParseDuration(strings.TrimSpace(spec[len("@every "):]))
+		if err != nil || every <= 0 {
+			return nil, fmt.Errorf("Invalid cron spec:%s", spec)
+		}
+		return &EverySchedule{Every: every}, nil
+	}
+	if expr, ok := descriptors[spec]; ok {
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("Invalid cron spec:%s, expect 5 or 6 fields, got %d", spec, len(fields))
+	}
+
+	schedule := &SpecSchedule{Location: loc}
+	all := []struct {
+		field  string
+		bounds bounds
+		bits   *uint64
+	}{
+		{fields[0], seconds, &schedule.Second},
+		{fields[1], minutes, &schedule.Minute},
+		{fields[2], hours, &schedule.Hour},
+		{fields[3], dom, &schedule.Dom},
+		{fields[4], months, &schedule.Month},
+		{fields[5], dow, &schedule.Dow},
+	}
+	for _, f := range all {
+		bits, err := parseField(f.field, f.bounds)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid cron spec:%s, err:%v", spec, err)
+		}
+		*f.bits = bits
+	}
+	// 星期 7 与 0 同为周日
+	if schedule.Dow&(1<<7) != 0 {
+		schedule.Dow = schedule.Dow&^(1<<7) | 1
+	}
+	return schedule, nil
+}
+
+// 解析单个字段，返回按位表示的取值集合
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, expr := range strings.Split(field, ",") {
+		rangeBits, err := parseRange(expr, b)
+		if err != nil {
+			return 0, err
+		}
+		bits |= rangeBits
+	}
+	return bits, nil
+}
+
+// 解析 *、a、a-b、*/n、a-b/n、a/n
+func parseRange(expr string, b bounds) (uint64, error) {
+	var (
+		start, end, step uint = 0, 0, 1
+		extra            uint64
+		err              error
+	)
+	rangeAndStep := strings.Split(expr, "/")
+	lowAndHigh := strings.Split(rangeAndStep[0], "-")
+	singleDigit := len(lowAndHigh) == 1
+
+	if lowAndHigh[0] == "*" || lowAndHigh[0] == "?" {
+		if !singleDigit {
+			return 0, fmt.Errorf("Invalid range:%s", expr)
+		}
+		start, end = b.min, b.max
+		extra = starBit
+	} else {
+		if start, err = parseValue(lowAndHigh[0], b); err != nil {
+			return 0, err
+		}
+		switch len(lowAndHigh) {
+		case 1:
+			end = start
+		case 2:
+			if end, err = parseValue(lowAndHigh[1], b); err != nil {
+				return 0, err
+			}
+		default:
+			return 0, fmt.Errorf("Invalid range:%s", expr)
+		}
+	}
+
+	switch len(rangeAndStep) {
+	case 1:
+	case 2:
+		n, err := strconv.ParseUint(rangeAndStep[1], 10, 32)
+		if err != nil || n == 0 {
+			return 0, fmt.Errorf("Invalid step:%s", expr)
+		}
+		step = uint(n)
+		// a/n 表示从 a 开始到最大值
+		if singleDigit && extra == 0 {
+			end = b.max
+		}
+		// */n 不视为 *
+		extra = 0
+	default:
+		return 0, fmt.Errorf("Invalid step:%s", expr)
+	}
+
+	if start < b.min || end > b.max || start > end {
+		return 0, fmt.Errorf("Value out of range [%d, %d]:%s", b.min, b.max, expr)
+	}
+	var bits uint64
+	for i := start; i <= end; i += step {
+		bits |= 1 << i
+	}
+	return bits | extra, nil
+}
+
+// 解析数字或英文缩写
+func parseValue(value string, b bounds) (uint, error) {
+	if n, ok := b.names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+	n, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid value:%s", value)
+	}
+	return uint(n), nil
+}
+
+// 按 cron 表达式计算的时间表，每个字段按位表示允许的取值
+type SpecSchedule struct {
+	Second, Minute, Hour, Dom, Month, Dow uint64
+	Location                              *time.Location
+}
+
+/*
+下一次执行时间：
+从下一秒开始按 月 -> 日 -> 时 -> 分 -> 秒 逐级查找，
+某一级进位到下一个周期时重新从月开始检查，最多查找 5 年
+*/
+func (s *SpecSchedule) Next(t time.Time) time.Time {
+	origin := t.Location()
+	loc := s.Location
+	if loc == nil {
+		loc = origin
+	}
+	t = t.In(loc)
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+
+	// 是否已经进位，进位后低级字段从最小值开始
+	added := false
+	yearLimit := t.Year() + 5
+
+WRAP:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for 1<<uint(t.Month())&s.Month == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto WRAP
+		}
+	}
+
+	for !s.dayMatches(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		}
+		t = t.AddDate(0, 0, 1)
+		// 夏令时切换时零点可能不存在，修正到当天零点附近
+		if t.Hour() != 0 {
+			if t.Hour() > 12 {
+				t = t.Add(time.Duration(24-t.Hour()) * time.Hour)
+			} else {
+				t = t.Add(time.Duration(-t.Hour()) * time.Hour)
+			}
+		}
+		if t.Day() == 1 {
+			goto WRAP
+		}
+	}
+
+	for 1<<uint(t.Hour())&s.Hour == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto WRAP
+		}
+	}
+
+	for 1<<uint(t.Minute())&s.Minute == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Minute)
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto WRAP
+		}
+	}
+
+	for 1<<uint(t.Second())&s.Second == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Second)
+		}
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto WRAP
+		}
+	}
+	return t.In(origin)
+}
+
+/*
+日与星期是否匹配：
+两者都有限制时满足其一即可，否则两者都需满足，与标准 cron 一致
+*/
+func (s *SpecSchedule) dayMatches(t time.Time) bool {
+	domMatch := 1<<uint(t.Day())&s.Dom > 0
+	dowMatch := 1<<uint(t.Weekday())&s.Dow > 0
+	if s.Dom&starBit > 0 || s.Dow&starBit > 0 {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// 固定间隔执行
+type EverySchedule struct {
+	Every time.Duration
+}
+
+// 下一次执行时间，间隔不小于 1 秒时对齐到整秒
+func (s *EverySchedule) Next(t time.Time) time.Time {
+	if s.Every >= time.Second {
+		return t.Add(s.Every - time.Duration(t.Nanosecond()))
+	}
+	return t.Add(s.Every)
+}
+
+// 从 from 开始的 n 次执行时间
+func NextTimes(schedule Schedule, from time.Time, n int) []time.Time {
+	times := make([]time.Time, 0, n)
+	for i := 0; i < n; i++ {
+		from = schedule.Next(from)
+		if from.IsZero() {
+			break
+		}
+		times = append(times, from)
+	}
+	return times
+}
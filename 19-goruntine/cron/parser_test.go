@@ -0,0 +1,80 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseNext(t *testing.T) {
+	layout := "2006-01-02 15:04:05"
+	tests := []struct {
+		spec string
+		from string
+		next string
+	}{
+		{"* * * * *", "2020-08-20 23:05:26", "2020-08-20 23:06:00"},
+		{"*/15 * * * * *", "2020-08-20 23:05:26", "2020-08-20 23:05:30"},
+		{"30 2 * * *", "2020-08-20 23:05:26", "2020-08-21 02:30:00"},
+		{"0 0 1 * *", "2020-12-20 23:05:26", "2021-01-01 00:00:00"},
+		{"0 9-17/4 * * mon-fri", "2020-08-21 17:00:00", "2020-08-24 09:00:00"},
+		{"0 0 * * 7", "2020-08-20 00:00:00", "2020-08-23 00:00:00"},
+		{"0 0 29 feb *", "2021-01-01 00:00:00", "2024-02-29 00:00:00"},
+		// 日与星期都有限制时满足其一即可
+		{"0 0 1 * 1", "2020-08-20 00:00:00", "2020-08-24 00:00:00"},
+		{"0 0 13 * ?", "2020-08-20 00:00:00", "2020-09-13 00:00:00"},
+		{"@daily", "2020-08-20 23:05:26", "2020-08-21 00:00:00"},
+		{"@hourly", "2020-08-20 23:05:26", "2020-08-21 00:00:00"},
+		{"@monthly", "2020-08-20 23:05:26", "2020-09-01 00:00:00"},
+		{"@every 5m", "2020-08-20 23:05:26", "2020-08-20 23:10:26"},
+	}
+	for _, test := range tests {
+		schedule, err := ParseInLocation(test.spec, time.UTC)
+		if err != nil {
+			t.Fatalf("Parse %s failed, err:%v", test.spec, err)
+		}
+		from, _ := time.ParseInLocation(layout, test.from, time.UTC)
+		if next := schedule.Next(from).Format(layout); next != test.next {
+			t.Fatalf("Wrong next for %s, expect:%s, got:%s", test.spec, test.next, next)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@every",
+		"@every -1s",
+		"CRON_TZ=Nowhere/City * * * * *",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Fatalf("Parse %q should fail", spec)
+		}
+	}
+}
+
+func TestParseTimezone(t *testing.T) {
+	schedule, err := Parse("CRON_TZ=Asia/Shanghai 0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2020, 8, 20, 0, 0, 0, 0, time.UTC)
+	next := schedule.Next(from)
+	// 上海 09:00 为 UTC 01:00，返回值保持输入时区
+	if expect := time.Date(2020, 8, 20, 1, 0, 0, 0, time.UTC); !next.Equal(expect) || next.Location() != time.UTC {
+		t.Fatalf("Wrong next, expect:%v, got:%v", expect, next)
+	}
+
+	times := NextTimes(schedule, from, 3)
+	if len(times) != 3 || times[2].Sub(times[0]) != 48*time.Hour {
+		t.Fatalf("Wrong next times:%v", times)
+	}
+}
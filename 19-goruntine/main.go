@@ -32,5 +32,5 @@ func main() {
 	//workpool.Progress(job, ret)
 	//data :=<- ret
 	//fmt.Println(data)
-	workpool.Start()
+	workpool.Start(10000)
 }
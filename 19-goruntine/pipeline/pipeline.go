@@ -0,0 +1,258 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// 处理函数返回 Skip 时丢弃该数据，不视为错误
+var Skip = errors.New("Skip")
+
+// 数据源，通过 emit 逐个发送数据，ctx 结束后 emit 返回错误
+type Source func(ctx context.Context, emit func(value interface{}) error) error
+
+// 阶段处理函数
+type Func func(ctx context.Context, value interface{}) (interface{}, error)
+
+// 终点处理函数
+type Sink func(ctx context.Context, value interface{}) error
+
+// 阶段执行失败
+type StageError struct {
+	Stage string
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("Stage[%s] failed, err:%v", e.Stage, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// 流经管道的数据，seq 为在当前阶段输入中的序号
+type item struct {
+	seq   uint64
+	value interface{}
+	skip  bool
+}
+
+/*
+处理阶段：
+workers 个协程并发处理（扇出），结果汇总到同一个输出管道（扇入），
+ordered 时按输入顺序输出，否则按完成顺序输出
+*/
+type Stage struct {
+	name    string
+	fn      Func
+	workers int
+	ordered bool
+	buffer  int
+}
+
+// 创建处理阶段，默认单协程
+func NewStage(name string, fn Func) *Stage {
+	return &Stage{
+		name:    name,
+		fn:      fn,
+		workers: 1,
+	}
+}
+
+// 设置并发协程数量
+func (s *Stage) Workers(n int) *Stage {
+	if n < 1 {
+		n = 1
+	}
+	s.workers = n
+	return s
+}
+
+// 按输入顺序输出，先完成的结果会缓存到前面的数据完成为止
+func (s *Stage) Ordered() *Stage {
+	s.ordered = true
+	return s
+}
+
+// 设置输出管道缓冲长度
+func (s *Stage) Buffer(n int) *Stage {
+	s.buffer = n
+	return s
+}
+
+/*
+管道：
+数据源 -> 处理阶段 ... -> 终点，
+任意阶段出错时取消 ctx，所有阶段停止并返回第一个错误
+*/
+type Pipeline struct {
+	source Source
+	stages []*Stage
+}
+
+// 创建管道
+func New(source Source) *Pipeline {
+	return &Pipeline{source: source}
+}
+
+// 追加处理阶段
+func (p *Pipeline) Then(stages ...*Stage) *Pipeline {
+	p.stages = append(p.stages, stages...)
+	return p
+}
+
+// 执行管道，每个结果交给 sink 处理，返回第一个错误
+func (p *Pipeline) Run(ctx context.Context, sink Sink) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		once     sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	out := p.emit(runCtx, fail)
+	for _, stage := range p.stages {
+		out = stage.run(runCtx, out, fail)
+	}
+	for it := range out {
+		if runCtx.Err() != nil {
+			continue
+		}
+		if err := sink(runCtx, it.value); err != nil {
+			fail(&StageError{Stage: "sink", Err: err})
+		}
+	}
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// 执行管道并按输出顺序收集所有结果
+func (p *Pipeline) Collect(ctx context.Context) ([]interface{}, error) {
+	var values []interface{}
+	err := p.Run(ctx, func(ctx context.Context, value interface{}) error {
+		values = append(values, value)
+		return nil
+	})
+	return values, err
+}
+
+// 执行数据源
+func (p *Pipeline) emit(ctx context.Context, fail func(err error)) <-chan item {
+	out := make(chan item)
+	go func() {
+		defer close(out)
+		var seq uint64
+		err := p.source(ctx, func(value interface{}) error {
+			select {
+			case out <- item{seq: seq, value: value}:
+				seq++
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			fail(&StageError{Stage: "source", Err: err})
+		}
+	}()
+	return out
+}
+
+// 启动处理协程与汇总协程
+func (s *Stage) run(ctx context.Context, in <-chan item, fail func(err error)) <-chan item {
+	results := make(chan item, s.workers)
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for it := range in {
+				if ctx.Err() != nil {
+					// 已取消时只消费输入，让上游尽快退出
+					continue
+				}
+				value, err := s.fn(ctx, it.value)
+				if err == Skip {
+					it.value, it.skip = nil, true
+				} else if err != nil {
+					fail(&StageError{Stage: s.name, Err: err})
+					continue
+				} else {
+					it.value = value
+				}
+				select {
+				case results <- it:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	out := make(chan item, s.buffer)
+	go func() {
+		defer close(out)
+		s.collect(ctx, results, out)
+	}()
+	return out
+}
+
+// 汇总结果，丢弃跳过的数据并重新编号
+func (s *Stage) collect(ctx context.Context, results <-chan item, out chan<- item) {
+	var (
+		seq     uint64
+		next    uint64
+		pending = make(map[uint64]item)
+	)
+	send := func(it item) bool {
+		if it.skip {
+			return true
+		}
+		select {
+		case out <- item{seq: seq, value: it.value}:
+			seq++
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	for it := range results {
+		if !s.ordered {
+			if !send(it) {
+				break
+			}
+			continue
+		}
+		pending[it.seq] = it
+		for {
+			ready, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			if !send(ready) {
+				break
+			}
+		}
+	}
+	// 提前退出时继续消费，保证处理协程不会阻塞
+	for range results {
+	}
+}
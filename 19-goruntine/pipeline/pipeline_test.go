@@ -0,0 +1,152 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 生成 0 ~ n-1
+func numbers(n int) Source {
+	return func(ctx context.Context, emit func(value interface{}) error) error {
+		for i := 0; i < n; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// 随机耗时后返回平方
+func square(ctx context.Context, value interface{}) (interface{}, error) {
+	time.Sleep(time.Duration(rand.Intn(200)) * time.Microsecond)
+	n := value.(int)
+	return n * n, nil
+}
+
+func TestPipelineOrdered(t *testing.T) {
+	values, err := New(numbers(100)).
+		Then(
+			NewStage("square", square).Workers(8).Ordered(),
+			NewStage("even", func(ctx context.Context, value interface{}) (interface{}, error) {
+				if value.(int)%2 != 0 {
+					return nil, Skip
+				}
+				return value, nil
+			}).Workers(4).Ordered(),
+		).
+		Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 50 {
+		t.Fatalf("Wrong count, expect:50, got:%d", len(values))
+	}
+	for i, value := range values {
+		if value.(int) != (2*i)*(2*i) {
+			t.Fatalf("Wrong order at %d, got:%v", i, value)
+		}
+	}
+}
+
+func TestPipelineUnordered(t *testing.T) {
+	var sum int
+	err := New(numbers(100)).
+		Then(NewStage("square", square).Workers(8).Buffer(16)).
+		Run(context.Background(), func(ctx context.Context, value interface{}) error {
+			sum += value.(int)
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != 328350 {
+		t.Fatalf("Wrong sum, expect:328350, got:%d", sum)
+	}
+}
+
+func TestPipelineError(t *testing.T) {
+	failed := errors.New("failed")
+	var processed int32
+	err := New(numbers(100000)).
+		Then(NewStage("check", func(ctx context.Context, value interface{}) (interface{}, error) {
+			atomic.AddInt32(&processed, 1)
+			if value.(int) == 10 {
+				return nil, failed
+			}
+			return value, nil
+		}).Workers(4)).
+		Run(context.Background(), func(ctx context.Context, value interface{}) error {
+			return nil
+		})
+	stageErr, ok := err.(*StageError)
+	if !ok || stageErr.Stage != "check" || !errors.Is(err, failed) {
+		t.Fatalf("Wrong error:%v", err)
+	}
+	if n := atomic.LoadInt32(&processed); n > 1000 {
+		t.Fatalf("Pipeline should short-circuit, processed:%d", n)
+	}
+
+	// 终点出错同样停止整个管道
+	err = New(numbers(100000)).
+		Then(NewStage("square", square).Workers(4)).
+		Run(context.Background(), func(ctx context.Context, value interface{}) error {
+			return failed
+		})
+	if stageErr, ok = err.(*StageError); !ok || stageErr.Stage != "sink" {
+		t.Fatalf("Wrong error:%v", err)
+	}
+}
+
+func TestPipelineCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var count int
+	err := New(numbers(1000000)).
+		Then(NewStage("square", square).Workers(4)).
+		Run(ctx, func(ctx context.Context, value interface{}) error {
+			count++
+			if count == 10 {
+				cancel()
+			}
+			return nil
+		})
+	if err != context.Canceled {
+		t.Fatalf("Wrong error:%v", err)
+	}
+}
+
+func TestMergeAndFan(t *testing.T) {
+	values, err := New(Merge(FromSlice(1, 2, 3), FromSlice(4, 5))).Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int
+	for _, value := range values {
+		got = append(got, value.(int))
+	}
+	sort.Ints(got)
+	if len(got) != 5 || got[0] != 1 || got[4] != 5 {
+		t.Fatalf("Wrong merged values:%v", got)
+	}
+
+	ctx := context.Background()
+	in := make(chan interface{})
+	go func() {
+		for i := 1; i <= 10; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+	var sum int
+	for value := range FanIn(ctx, FanOut(ctx, in, 3)...) {
+		sum += value.(int)
+	}
+	if sum != 55 {
+		t.Fatalf("Wrong sum, expect:55, got:%d", sum)
+	}
+}
@@ -0,0 +1,112 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+)
+
+// 依次发送切片中的数据
+func FromSlice(values ...interface{}) Source {
+	return func(ctx context.Context, emit func(value interface{}) error) error {
+		for _, value := range values {
+			if err := emit(value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// 发送管道中的数据，直到管道关闭
+func FromChan(ch <-chan interface{}) Source {
+	return func(ctx context.Context, emit func(value interface{}) error) error {
+		for {
+			select {
+			case value, ok := <-ch:
+				if !ok {
+					return nil
+				}
+				if err := emit(value); err != nil {
+					return err
+				}
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// 并发执行多个数据源，合并为一个数据源，输出顺序不确定
+func Merge(sources ...Source) Source {
+	return func(ctx context.Context, emit func(value interface{}) error) error {
+		var (
+			mu       sync.Mutex
+			wg       sync.WaitGroup
+			once     sync.Once
+			firstErr error
+		)
+		// emit 不支持并发调用，加锁串行发送
+		locked := func(value interface{}) error {
+			mu.Lock()
+			defer mu.Unlock()
+			return emit(value)
+		}
+		for _, source := range sources {
+			wg.Add(1)
+			go func(source Source) {
+				defer wg.Done()
+				if err := source(ctx, locked); err != nil {
+					once.Do(func() {
+						firstErr = err
+					})
+				}
+			}(source)
+		}
+		wg.Wait()
+		return firstErr
+	}
+}
+
+// 扇出：n 个协程竞争消费 in，各自写入独立的输出管道
+func FanOut(ctx context.Context, in <-chan interface{}, n int) []<-chan interface{} {
+	outs := make([]<-chan interface{}, n)
+	for i := 0; i < n; i++ {
+		out := make(chan interface{})
+		outs[i] = out
+		go func() {
+			defer close(out)
+			for value := range in {
+				select {
+				case out <- value:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	return outs
+}
+
+// 扇入：合并多个管道，全部关闭后关闭输出管道
+func FanIn(ctx context.Context, ins ...<-chan interface{}) <-chan interface{} {
+	out := make(chan interface{})
+	var wg sync.WaitGroup
+	for _, in := range ins {
+		wg.Add(1)
+		go func(in <-chan interface{}) {
+			defer wg.Done()
+			for value := range in {
+				select {
+				case out <- value:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(in)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
@@ -0,0 +1,135 @@
+package workpool
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+	"testing"
+)
+
+// 极小任务
+func tinyTask(counter *int64) Task {
+	return func(ctx context.Context) error {
+		atomic.AddInt64(counter, 1)
+		return nil
+	}
+}
+
+// 耗时较长的任务
+func largeTask(counter *int64) Task {
+	return func(ctx context.Context) error {
+		var sum int
+		for i := 0; i < 20000; i++ {
+			sum += DigitSum(i)
+		}
+		atomic.AddInt64(counter, int64(sum&1))
+		return nil
+	}
+}
+
+// 基于 jobChan 的协程池
+func benchmarkChannelPool(b *testing.B, newTask func(counter *int64) Task) {
+	pool, err := NewWithOptions(Options{Size: runtime.GOMAXPROCS(0)})
+	if err != nil {
+		b.Fatal(err)
+	}
+	var counter int64
+	task := newTask(&counter)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err = pool.Submit(context.Background(), task); err != nil {
+			b.Fatal(err)
+		}
+	}
+	pool.StopWait()
+}
+
+// 工作窃取协程池
+func benchmarkStealingPool(b *testing.B, newTask func(counter *int64) Task) {
+	pool, err := NewStealingPool(StealingOptions{Size: runtime.GOMAXPROCS(0)})
+	if err != nil {
+		b.Fatal(err)
+	}
+	var counter int64
+	task := newTask(&counter)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err = pool.Submit(task); err != nil {
+			b.Fatal(err)
+		}
+	}
+	pool.StopWait()
+}
+
+func BenchmarkChannelPoolTiny(b *testing.B) {
+	benchmarkChannelPool(b, tinyTask)
+}
+
+func BenchmarkStealingPoolTiny(b *testing.B) {
+	benchmarkStealingPool(b, tinyTask)
+}
+
+func BenchmarkChannelPoolLarge(b *testing.B) {
+	benchmarkChannelPool(b, largeTask)
+}
+
+func BenchmarkStealingPoolLarge(b *testing.B) {
+	benchmarkStealingPool(b, largeTask)
+}
+
+// 任务内拆分出大量子任务，工作窃取协程池的典型场景
+func BenchmarkStealingPoolSpawn(b *testing.B) {
+	pool, err := NewStealingPool(StealingOptions{Size: runtime.GOMAXPROCS(0)})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer pool.Stop()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var sum int64
+		if err = pool.Submit(spawnSum(0, 1<<14, &sum)); err != nil {
+			b.Fatal(err)
+		}
+		pool.Wait()
+	}
+}
+
+// 通道协程池中等价的子任务拆分，子任务通过 Submit 重新进入共享队列
+func BenchmarkChannelPoolSpawn(b *testing.B) {
+	pool, err := NewWithOptions(Options{Size: runtime.GOMAXPROCS(0), QueueSize: 1 << 15})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer pool.Stop()
+	var split func(low, high int, sum *int64, done chan<- struct{}) Task
+	split = func(low, high int, sum *int64, done chan<- struct{}) Task {
+		return func(ctx context.Context) error {
+			if high-low <= 8 {
+				for i := low; i < high; i++ {
+					atomic.AddInt64(sum, int64(i))
+				}
+				done <- struct{}{}
+				return nil
+			}
+			// 父任务返回后其 ctx 会被取消，子任务不能继承
+			mid := (low + high) / 2
+			if _, err := pool.Submit(context.Background(), split(low, mid, sum, done)); err != nil {
+				return err
+			}
+			_, err := pool.Submit(context.Background(), split(mid, high, sum, done))
+			return err
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var sum int64
+		leaves := (1 << 14) / 8
+		done := make(chan struct{}, leaves)
+		if _, err = pool.Submit(context.Background(), split(0, 1<<14, &sum, done)); err != nil {
+			b.Fatal(err)
+		}
+		for j := 0; j < leaves; j++ {
+			<-done
+		}
+	}
+}
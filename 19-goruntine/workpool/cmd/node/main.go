@@ -0,0 +1,92 @@
+// 分布式协程池节点：
+//
+//	node -mode dispatcher -addr 127.0.0.1:9090 -jobs 10000
+//	node -mode worker -addr 127.0.0.1:9090 -capacity 8 -codec gob
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/learning_golang/19-goruntine/workpool"
+)
+
+func main() {
+	mode := flag.String("mode", "worker", "dispatcher or worker")
+	addr := flag.String("addr", "127.0.0.1:9090", "dispatcher address")
+	jobs := flag.Int("jobs", 10000, "number of digit-sum jobs to submit, dispatcher only")
+	capacity := flag.Int("capacity", 4, "concurrent jobs, worker only")
+	codec := flag.String("codec", workpool.CodecJSON, "json or gob, worker only")
+	id := flag.String("id", "", "worker id, default hostname:pid")
+	flag.Parse()
+
+	// 收到退出信号时取消，远程协程断开后调度器重新分配其任务
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	var err error
+	switch *mode {
+	case "dispatcher":
+		err = dispatch(ctx, *addr, *jobs)
+	case "worker":
+		err = workpool.RunRemoteWorker(ctx, *addr, workpool.RemoteOptions{
+			Id:       *id,
+			Capacity: *capacity,
+			Codec:    *codec,
+		})
+	default:
+		err = fmt.Errorf("Unknown mode:%s", *mode)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// 提交数字求和任务并打印结果，全部完成后退出
+func dispatch(ctx context.Context, addr string, jobNum int) error {
+	d, err := workpool.NewDispatcher(addr, workpool.DispatcherOptions{Results: true})
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	fmt.Printf("Dispatcher listening on %s\n", d.Addr())
+
+	go func() {
+		for id := 1; id <= jobNum; id++ {
+			job := &workpool.Job{Id: id, Number: rand.Int()}
+			if err := d.Submit(ctx, job); err != nil {
+				fmt.Printf("Failed to submit job, err:%v\n", err)
+				return
+			}
+		}
+	}()
+
+	var failed int
+	for i := 0; i < jobNum; i++ {
+		select {
+		case ret := <-d.Results():
+			if ret.Err != nil {
+				failed++
+				fmt.Printf("Job:id=%d,number=%d; err=%v\n", ret.Job.Id, ret.Job.Number, ret.Err)
+				continue
+			}
+			fmt.Printf("Job:id=%d,number=%d; result=%d\n", ret.Job.Id, ret.Job.Number, ret.Sum)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	fmt.Printf("Done: jobs=%d, failed=%d, workers=%d\n", jobNum, failed, len(d.Workers()))
+	return nil
+}
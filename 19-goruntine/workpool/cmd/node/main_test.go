@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 设置该环境变量时测试程序作为节点运行，参数以空格分隔
+const nodeArgsEnv = "WORKPOOL_NODE_ARGS"
+
+func TestMain(m *testing.M) {
+	if args := os.Getenv(nodeArgsEnv); args != "" {
+		os.Args = append([]string{os.Args[0]}, strings.Fields(args)...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// 独立进程中运行的节点
+type node struct {
+	cmd   *exec.Cmd
+	lines chan string
+	done  chan error
+}
+
+// 启动节点进程，按行读取标准输出
+func startNode(t *testing.T, args ...string) *node {
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), nodeArgsEnv+"="+strings.Join(args, " "))
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	n := &node{cmd: cmd, lines: make(chan string, 100), done: make(chan error, 1)}
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "Job:") {
+				n.lines <- line
+				continue
+			}
+			// 任务结果行较多，读取不及时时丢弃，避免阻塞节点
+			select {
+			case n.lines <- line:
+			default:
+			}
+		}
+		close(n.lines)
+		_, _ = io.Copy(ioutil.Discard, stdout)
+		n.done <- cmd.Wait()
+	}()
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		<-n.done
+	})
+	return n
+}
+
+// 等待以 prefix 开头的输出行
+func (n *node) waitLine(t *testing.T, prefix string, timeout time.Duration) string {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case line, ok := <-n.lines:
+			if !ok {
+				t.Fatalf("Node exited before printing %q", prefix)
+			}
+			if strings.HasPrefix(line, prefix) {
+				return line
+			}
+		case <-deadline:
+			t.Fatalf("Timeout waiting for %q", prefix)
+		}
+	}
+}
+
+// 等待节点退出
+func (n *node) wait(t *testing.T, timeout time.Duration) error {
+	select {
+	case err := <-n.done:
+		n.done <- err
+		return err
+	case <-time.After(timeout):
+		t.Fatalf("Node %v did not exit", n.cmd.Args)
+		return nil
+	}
+}
+
+// 启动调度器进程并返回监听地址
+func startDispatcher(t *testing.T, jobs string) (*node, string) {
+	d := startNode(t, "-mode", "dispatcher", "-addr", "127.0.0.1:0", "-jobs", jobs)
+	line := d.waitLine(t, "Dispatcher listening on ", 5*time.Second)
+	return d, strings.TrimPrefix(line, "Dispatcher listening on ")
+}
+
+func TestNodes(t *testing.T) {
+	d, addr := startDispatcher(t, "500")
+	w1 := startNode(t, "-mode", "worker", "-addr", addr, "-capacity", "4", "-id", "w1")
+	w2 := startNode(t, "-mode", "worker", "-addr", addr, "-capacity", "2", "-codec", "gob", "-id", "w2")
+
+	line := d.waitLine(t, "Done:", 20*time.Second)
+	if !strings.HasPrefix(line, "Done: jobs=500, failed=0") {
+		t.Fatalf("Wrong summary:%s", line)
+	}
+	if err := d.wait(t, 5*time.Second); err != nil {
+		t.Fatalf("Dispatcher failed, err:%v", err)
+	}
+	// 调度器退出后远程协程断开并退出
+	w1.wait(t, 10*time.Second)
+	w2.wait(t, 10*time.Second)
+}
+
+func TestNodesInterrupt(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Interrupt signal is not supported on windows")
+	}
+	d, addr := startDispatcher(t, "1000000")
+	w := startNode(t, "-mode", "worker", "-addr", addr, "-capacity", "4")
+	d.waitLine(t, "Job:", 10*time.Second)
+
+	// 停止读取结果后关闭调度器，队列与执行中的任务结束时不能阻塞退出
+	if err := d.cmd.Process.Signal(os.Interrupt); err != nil {
+		t.Fatal(err)
+	}
+	d.wait(t, 10*time.Second)
+	w.wait(t, 10*time.Second)
+}
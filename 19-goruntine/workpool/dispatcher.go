@@ -0,0 +1,460 @@
+package workpool
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 调度器参数
+type DispatcherOptions struct {
+	// 任务队列长度，默认 QueueSize
+	QueueSize int
+	// 心跳超时，默认 HeartbeatTimeout
+	HeartbeatTimeout time.Duration
+	// 向远程协程发送心跳的间隔，默认 HeartbeatInterval，需小于远程协程的心跳超时
+	HeartbeatInterval time.Duration
+	// 是否将结果写入 Results 管道，开启后调用方需要持续消费
+	Results bool
+}
+
+// 远程协程状态
+type WorkerInfo struct {
+	Id       string
+	Addr     string
+	Capacity int
+	// 已下发未返回结果的任务数
+	InFlight int
+}
+
+/*
+网络调度器：
+监听 TCP 端口，远程协程连接后注册并发数，调度器按空闲额度下发任务，
+远程协程执行完成后返回结果并归还额度；
+双方定期发送心跳，心跳超时或连接断开时，该协程上未完成的任务重新排队交给其他协程
+*/
+type Dispatcher struct {
+	options   DispatcherOptions
+	listener  net.Listener
+	queue     chan *Job
+	retChan   chan *Result
+	mu        sync.Mutex
+	requeued  []*Job
+	signal    chan struct{}
+	conns     map[*remoteConn]struct{}
+	seq       uint64
+	stopped   bool
+	submitMu  sync.RWMutex
+	closed    chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// 调度器中的远程协程连接
+type remoteConn struct {
+	d        *Dispatcher
+	conn     net.Conn
+	encoder  encoder
+	writeMu  sync.Mutex
+	id       string
+	capacity int
+	credits  chan struct{}
+	mu       sync.Mutex
+	inflight map[uint64]*Job
+	closed   chan struct{}
+	once     sync.Once
+}
+
+// 监听 addr 并创建调度器，addr 为 127.0.0.1:0 时随机选择端口
+func NewDispatcher(addr string, options DispatcherOptions) (*Dispatcher, error) {
+	if options.QueueSize <= 0 {
+		options.QueueSize = QueueSize
+	}
+	if options.HeartbeatTimeout <= 0 {
+		options.HeartbeatTimeout = HeartbeatTimeout
+	}
+	if options.HeartbeatInterval <= 0 {
+		options.HeartbeatInterval = HeartbeatInterval
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	d := &Dispatcher{
+		options:  options,
+		listener: listener,
+		queue:    make(chan *Job, options.QueueSize),
+		signal:   make(chan struct{}, 1),
+		conns:    make(map[*remoteConn]struct{}),
+		closed:   make(chan struct{}),
+	}
+	if options.Results {
+		d.retChan = make(chan *Result, options.QueueSize)
+	}
+	d.wg.Add(1)
+	go d.accept()
+	return d, nil
+}
+
+// 监听地址
+func (d *Dispatcher) Addr() net.Addr {
+	return d.listener.Addr()
+}
+
+// 任务结果管道，调度器关闭后关闭；未开启 Results 时返回 nil
+func (d *Dispatcher) Results() <-chan *Result {
+	return d.retChan
+}
+
+// 提交任务，任务需可序列化：设置 Name 与 Payload 的具名任务，或按 Number 求和的任务
+func (d *Dispatcher) Submit(ctx context.Context, job *Job) error {
+	if job.Task != nil && job.Name == "" {
+		return errors.New("Task can not be sent to remote workers, use Name and Payload")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	job.ctx = ctx
+	job.done = make(chan struct{})
+	job.submitted = time.Now()
+
+	d.submitMu.RLock()
+	defer d.submitMu.RUnlock()
+	if d.isStopped() {
+		return ErrStopped
+	}
+	select {
+	case d.queue <- job:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-d.closed:
+		return ErrStopped
+	}
+}
+
+// 已连接的远程协程
+func (d *Dispatcher) Workers() []WorkerInfo {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	infos := make([]WorkerInfo, 0, len(d.conns))
+	for c := range d.conns {
+		c.mu.Lock()
+		infos = append(infos, WorkerInfo{
+			Id:       c.id,
+			Addr:     c.conn.RemoteAddr().String(),
+			Capacity: c.capacity,
+			InFlight: len(c.inflight),
+		})
+		c.mu.Unlock()
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Id < infos[j].Id
+	})
+	return infos
+}
+
+// 关闭调度器：断开所有远程协程，未完成的任务以 ErrStopped 结束
+func (d *Dispatcher) Close() {
+	d.closeOnce.Do(func() {
+		close(d.closed)
+		d.listener.Close()
+		d.mu.Lock()
+		d.stopped = true
+		conns := make([]*remoteConn, 0, len(d.conns))
+		for c := range d.conns {
+			conns = append(conns, c)
+		}
+		d.mu.Unlock()
+		for _, c := range conns {
+			c.close()
+		}
+		d.wg.Wait()
+
+		// 等待提交中的任务写入队列后统一结束
+		d.submitMu.Lock()
+		d.submitMu.Unlock()
+	drain:
+		for {
+			select {
+			case job := <-d.queue:
+				d.finish(job, 0, ErrStopped)
+			default:
+				break drain
+			}
+		}
+		d.mu.Lock()
+		requeued := d.requeued
+		d.requeued = nil
+		d.mu.Unlock()
+		for _, job := range requeued {
+			d.finish(job, 0, ErrStopped)
+		}
+		if d.retChan != nil {
+			close(d.retChan)
+		}
+	})
+}
+
+// 是否已关闭
+func (d *Dispatcher) isStopped() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.stopped
+}
+
+// 接收远程协程连接
+func (d *Dispatcher) accept() {
+	defer d.wg.Done()
+	for {
+		conn, err := d.listener.Accept()
+		if err != nil {
+			return
+		}
+		d.wg.Add(1)
+		go d.serve(conn)
+	}
+}
+
+// 处理单个远程协程：握手、注册，然后分别处理下发与接收
+func (d *Dispatcher) serve(conn net.Conn) {
+	defer d.wg.Done()
+	c := &remoteConn{
+		d:        d,
+		conn:     conn,
+		inflight: make(map[uint64]*Job),
+		closed:   make(chan struct{}),
+	}
+	defer c.close()
+
+	conn.SetReadDeadline(time.Now().Add(d.options.HeartbeatTimeout))
+	reader := bufio.NewReader(conn)
+	codec, err := readHandshake(reader)
+	if err != nil {
+		return
+	}
+	dec, err := newDecoder(codec, reader)
+	if err != nil {
+		return
+	}
+	if c.encoder, err = newEncoder(codec, conn); err != nil {
+		return
+	}
+	var register message
+	if err = dec.Decode(&register); err != nil || register.Type != msgRegister || register.Capacity <= 0 {
+		return
+	}
+	c.id = register.WorkerId
+	c.capacity = register.Capacity
+	c.credits = make(chan struct{}, register.Capacity)
+	for i := 0; i < register.Capacity; i++ {
+		c.credits <- struct{}{}
+	}
+
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		return
+	}
+	d.conns[c] = struct{}{}
+	d.mu.Unlock()
+
+	d.wg.Add(2)
+	go c.send()
+	go c.heartbeat()
+	c.receive(dec)
+}
+
+// 下一个待下发的任务，优先取重新排队的任务
+func (d *Dispatcher) next(closed <-chan struct{}) *Job {
+	for {
+		d.mu.Lock()
+		if len(d.requeued) > 0 {
+			job := d.requeued[0]
+			d.requeued = d.requeued[1:]
+			if len(d.requeued) > 0 {
+				d.notify()
+			}
+			d.mu.Unlock()
+			return job
+		}
+		d.mu.Unlock()
+
+		select {
+		case job := <-d.queue:
+			return job
+		case <-d.signal:
+		case <-closed:
+			return nil
+		}
+	}
+}
+
+// 通知有重新排队的任务，调用方持有锁
+func (d *Dispatcher) notify() {
+	select {
+	case d.signal <- struct{}{}:
+	default:
+	}
+}
+
+// 任务重新排队，调度器已关闭时直接结束
+func (d *Dispatcher) requeue(job *Job) {
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		d.finish(job, 0, ErrStopped)
+		return
+	}
+	d.requeued = append(d.requeued, job)
+	d.notify()
+	d.mu.Unlock()
+}
+
+// 记录任务结果并通知等待方，关闭后调用方可能不再读取 Results，管道已满时丢弃结果
+func (d *Dispatcher) finish(job *Job, sum int, err error) {
+	job.err = err
+	close(job.done)
+	if d.retChan == nil {
+		return
+	}
+	result := &Result{Job: job, Sum: sum, Err: err}
+	select {
+	case d.retChan <- result:
+	case <-d.closed:
+		select {
+		case d.retChan <- result:
+		default:
+		}
+	}
+}
+
+// 按空闲额度下发任务
+func (c *remoteConn) send() {
+	defer c.d.wg.Done()
+	for {
+		select {
+		case <-c.credits:
+		case <-c.closed:
+			return
+		}
+		job := c.d.next(c.closed)
+		if job == nil {
+			return
+		}
+		if err := job.ctx.Err(); err != nil {
+			c.d.finish(job, 0, err)
+			c.credits <- struct{}{}
+			continue
+		}
+
+		c.d.mu.Lock()
+		c.d.seq++
+		id := c.d.seq
+		c.d.mu.Unlock()
+		c.mu.Lock()
+		select {
+		case <-c.closed:
+			// 连接已关闭，inflight 不会再被处理
+			c.mu.Unlock()
+			c.d.requeue(job)
+			return
+		default:
+		}
+		c.inflight[id] = job
+		c.mu.Unlock()
+
+		err := c.write(&message{
+			Type:    msgJob,
+			JobId:   id,
+			Name:    job.Name,
+			Number:  job.Number,
+			Payload: job.Payload,
+		})
+		if err != nil {
+			// 连接关闭时统一重新排队
+			c.close()
+			return
+		}
+	}
+}
+
+// 写入消息，下发与心跳在不同协程中写入，需加锁
+func (c *remoteConn) write(msg *message) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(c.d.options.HeartbeatTimeout))
+	return c.encoder.Encode(msg)
+}
+
+// 定期发送心跳，远程协程据此发现调度器已退出
+func (c *remoteConn) heartbeat() {
+	defer c.d.wg.Done()
+	ticker := time.NewTicker(c.d.options.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if c.write(&message{Type: msgHeartbeat}) != nil {
+				c.close()
+				return
+			}
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+// 接收结果与心跳，超过心跳超时时间未收到任何消息则断开
+func (c *remoteConn) receive(dec decoder) {
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(c.d.options.HeartbeatTimeout))
+		var msg message
+		if err := dec.Decode(&msg); err != nil {
+			return
+		}
+		if msg.Type != msgResult {
+			continue
+		}
+		c.mu.Lock()
+		job, ok := c.inflight[msg.JobId]
+		delete(c.inflight, msg.JobId)
+		c.mu.Unlock()
+		if !ok {
+			continue
+		}
+		var err error
+		if msg.Err != "" {
+			err = errors.New(msg.Err)
+		}
+		c.d.finish(job, msg.Sum, err)
+		c.credits <- struct{}{}
+	}
+}
+
+// 断开连接，未完成的任务重新排队
+func (c *remoteConn) close() {
+	c.once.Do(func() {
+		close(c.closed)
+		c.conn.Close()
+
+		c.d.mu.Lock()
+		delete(c.d.conns, c)
+		c.d.mu.Unlock()
+
+		c.mu.Lock()
+		jobs := make([]*Job, 0, len(c.inflight))
+		for id, job := range c.inflight {
+			jobs = append(jobs, job)
+			delete(c.inflight, id)
+		}
+		c.mu.Unlock()
+		for _, job := range jobs {
+			c.d.requeue(job)
+		}
+	})
+}
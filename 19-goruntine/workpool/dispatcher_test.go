@@ -0,0 +1,247 @@
+package workpool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+)
+
+// 启动远程协程，测试结束时退出
+func startRemoteWorker(t *testing.T, addr string, options RemoteOptions) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := RunRemoteWorker(ctx, addr, options); err != nil {
+			t.Logf("Remote worker %s exited, err:%v", options.Id, err)
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// 等待远程协程注册
+func waitWorkers(t *testing.T, d *Dispatcher, n int) {
+	deadline := time.Now().Add(2 * time.Second)
+	for len(d.Workers()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Workers not registered, expect:%d, got:%d", n, len(d.Workers()))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	d, err := NewDispatcher("127.0.0.1:0", DispatcherOptions{Results: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	handlers := map[string]Handler{
+		"fail": func(ctx context.Context, payload []byte) error {
+			return errors.New(string(payload))
+		},
+	}
+	startRemoteWorker(t, d.Addr().String(), RemoteOptions{Id: "json", Capacity: 2, Handlers: handlers})
+	startRemoteWorker(t, d.Addr().String(), RemoteOptions{Id: "gob", Capacity: 3, Codec: CodecGob, Handlers: handlers})
+	waitWorkers(t, d, 2)
+	if workers := d.Workers(); workers[0].Id != "gob" || workers[0].Capacity != 3 {
+		t.Fatalf("Wrong workers:%+v", workers)
+	}
+
+	for i := 1; i <= 50; i++ {
+		if err = d.Submit(context.Background(), &Job{Id: i, Number: i * 111}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	failed := &Job{Id: 51, Name: "fail", Payload: []byte("remote failed")}
+	if err = d.Submit(context.Background(), failed); err != nil {
+		t.Fatal(err)
+	}
+	if err = d.Submit(context.Background(), &Job{Task: func(ctx context.Context) error { return nil }}); err == nil {
+		t.Fatal("Local task should be rejected")
+	}
+
+	for i := 0; i < 51; i++ {
+		ret := <-d.Results()
+		if ret.Job.Id == 51 {
+			if ret.Err == nil || ret.Err.Error() != "remote failed" {
+				t.Fatalf("Wrong error:%v", ret.Err)
+			}
+			continue
+		}
+		if ret.Err != nil || ret.Sum != DigitSum(ret.Job.Number) {
+			t.Fatalf("Wrong result for job %d, sum:%d, err:%v", ret.Job.Id, ret.Sum, ret.Err)
+		}
+	}
+	if err = failed.Wait(); err == nil {
+		t.Fatal("Job.Wait should return remote error")
+	}
+}
+
+// 注册后领取任务但不再响应的远程协程
+func hangingWorker(t *testing.T, addr string, capacity int) (net.Conn, *json.Decoder) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = writeHandshake(conn, CodecJSON); err != nil {
+		t.Fatal(err)
+	}
+	if err = json.NewEncoder(conn).Encode(&message{Type: msgRegister, WorkerId: "hanging", Capacity: capacity}); err != nil {
+		t.Fatal(err)
+	}
+	return conn, json.NewDecoder(bufio.NewReader(conn))
+}
+
+func TestDispatcherRequeue(t *testing.T) {
+	d, err := NewDispatcher("127.0.0.1:0", DispatcherOptions{HeartbeatTimeout: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	conn, dec := hangingWorker(t, d.Addr().String(), 3)
+	defer conn.Close()
+	waitWorkers(t, d, 1)
+
+	var jobs []*Job
+	for i := 1; i <= 3; i++ {
+		job := &Job{Id: i, Number: i}
+		if err = d.Submit(context.Background(), job); err != nil {
+			t.Fatal(err)
+		}
+		jobs = append(jobs, job)
+	}
+	for i := 0; i < 3; {
+		var msg message
+		if err = dec.Decode(&msg); err != nil {
+			t.Fatal(err)
+		}
+		switch msg.Type {
+		case msgJob:
+			i++
+		case msgHeartbeat:
+		default:
+			t.Fatalf("Hanging worker should receive jobs, msg:%+v", msg)
+		}
+	}
+
+	// 心跳超时后任务交给正常的远程协程
+	startRemoteWorker(t, d.Addr().String(), RemoteOptions{Id: "healthy", Capacity: 2, HeartbeatInterval: 50 * time.Millisecond})
+	for _, job := range jobs {
+		select {
+		case <-job.Done():
+		case <-time.After(3 * time.Second):
+			t.Fatalf("Job %d not requeued", job.Id)
+		}
+		if err = job.Wait(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if workers := d.Workers(); len(workers) != 1 || workers[0].Id != "healthy" {
+		t.Fatalf("Hanging worker should be removed, workers:%+v", workers)
+	}
+}
+
+func TestDispatcherClose(t *testing.T) {
+	d, err := NewDispatcher("127.0.0.1:0", DispatcherOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, _ := hangingWorker(t, d.Addr().String(), 1)
+	defer conn.Close()
+	waitWorkers(t, d, 1)
+
+	var jobs []*Job
+	for i := 0; i < 3; i++ {
+		job := &Job{Name: fmt.Sprintf("job-%d", i)}
+		if err = d.Submit(context.Background(), job); err != nil {
+			t.Fatal(err)
+		}
+		jobs = append(jobs, job)
+	}
+	d.Close()
+	for _, job := range jobs {
+		if err = job.Wait(); err != ErrStopped {
+			t.Fatalf("Wrong error:%v", err)
+		}
+	}
+	if err = d.Submit(context.Background(), &Job{Number: 1}); err != ErrStopped {
+		t.Fatalf("Submit after close should fail, err:%v", err)
+	}
+}
+
+func TestDispatcherCloseWithoutReader(t *testing.T) {
+	d, err := NewDispatcher("127.0.0.1:0", DispatcherOptions{QueueSize: 2, Results: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, dec := hangingWorker(t, d.Addr().String(), 3)
+	defer conn.Close()
+	waitWorkers(t, d, 1)
+
+	for i := 0; i < 5; i++ {
+		if err = d.Submit(context.Background(), &Job{Id: i, Number: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 3; {
+		var msg message
+		if err = dec.Decode(&msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Type == msgJob {
+			i++
+		}
+	}
+	// 结果数量超过管道容量且没有读取方时，关闭不会阻塞
+	closed := make(chan struct{})
+	go func() {
+		d.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Close blocked on unread results")
+	}
+}
+
+func TestRemoteWorkerDispatcherGone(t *testing.T) {
+	// 接受连接但从不发送消息的调度器
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = io.Copy(ioutil.Discard, conn)
+	}()
+
+	start := time.Now()
+	err = RunRemoteWorker(context.Background(), listener.Addr().String(), RemoteOptions{
+		HeartbeatInterval: 20 * time.Millisecond,
+		HeartbeatTimeout:  100 * time.Millisecond,
+	})
+	if err == nil {
+		t.Fatal("Remote worker should fail without dispatcher heartbeat")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Dead dispatcher detected too late, elapsed:%v", elapsed)
+	}
+}
@@ -0,0 +1,119 @@
+package workpool
+
+import (
+	"context"
+	"sync"
+)
+
+/*
+任务组：
+Go 提交的任务全部完成后 Wait 返回第一个错误，
+开启 cancelOnError 时第一个错误出现后取消 Context，其余任务尽快结束
+*/
+type Group struct {
+	pool          *Pool
+	ctx           context.Context
+	cancel        context.CancelFunc
+	cancelOnError bool
+	wg            sync.WaitGroup
+	errOnce       sync.Once
+	err           error
+}
+
+// 创建任务组，pool 为空时每个任务在新协程中执行
+func NewGroup(ctx context.Context, pool *Pool, cancelOnError bool) *Group {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group{
+		pool:          pool,
+		ctx:           ctx,
+		cancel:        cancel,
+		cancelOnError: cancelOnError,
+	}
+}
+
+// 任务组的 ctx，出错取消或 Wait 返回后结束
+func (g *Group) Context() context.Context {
+	return g.ctx
+}
+
+// 提交任务，提交失败时同样记为任务组错误
+func (g *Group) Go(task Task) error {
+	g.wg.Add(1)
+	if g.pool == nil {
+		go func() {
+			defer g.wg.Done()
+			g.fail(protect(func() error {
+				return task(g.ctx)
+			}))
+		}()
+		return nil
+	}
+
+	job, err := g.pool.Submit(g.ctx, task)
+	if err != nil {
+		g.fail(err)
+		g.wg.Done()
+		return err
+	}
+	go func() {
+		defer g.wg.Done()
+		g.fail(job.Wait())
+	}()
+	return nil
+}
+
+// 等待所有任务完成，返回第一个错误
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
+
+// 记录第一个错误
+func (g *Group) fail(err error) {
+	if err == nil {
+		return
+	}
+	g.errOnce.Do(func() {
+		g.err = err
+		if g.cancelOnError {
+			g.cancel()
+		}
+	})
+}
+
+// 映射函数
+type MapFunc func(ctx context.Context, input interface{}) (interface{}, error)
+
+/*
+以 concurrency 个协程并发处理 inputs，结果按输入顺序返回；
+任一输入出错时取消其余任务并返回第一个错误，此时未完成的结果为 nil
+*/
+func Map(ctx context.Context, inputs []interface{}, fn MapFunc, concurrency int) ([]interface{}, error) {
+	if concurrency <= 0 {
+		return nil, ErrInvalidSize
+	}
+	pool, err := NewWithOptions(Options{Size: concurrency, QueueSize: concurrency})
+	if err != nil {
+		return nil, err
+	}
+	defer pool.StopWait()
+
+	results := make([]interface{}, len(inputs))
+	group := NewGroup(ctx, pool, true)
+	for i, input := range inputs {
+		i, input := i, input
+		err = group.Go(func(ctx context.Context) error {
+			value, err := fn(ctx, input)
+			results[i] = value
+			return err
+		})
+		if err != nil {
+			break
+		}
+	}
+	return results, group.Wait()
+}
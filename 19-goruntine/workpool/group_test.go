@@ -0,0 +1,173 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMap(t *testing.T) {
+	inputs := make([]interface{}, 100)
+	for i := range inputs {
+		inputs[i] = i
+	}
+	results, err := Map(context.Background(), inputs, func(ctx context.Context, input interface{}) (interface{}, error) {
+		time.Sleep(time.Duration(rand.Intn(100)) * time.Microsecond)
+		return DigitSum(input.(int) * 111), nil
+	}, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, result := range results {
+		if result.(int) != DigitSum(i*111) {
+			t.Fatalf("Wrong result at %d:%v", i, result)
+		}
+	}
+
+	// 出错后取消其余任务，阻塞的任务随 ctx 结束
+	failed := errors.New("failed")
+	_, err = Map(context.Background(), inputs, func(ctx context.Context, input interface{}) (interface{}, error) {
+		if input.(int) == 3 {
+			return nil, failed
+		}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, 4)
+	if err != failed {
+		t.Fatalf("Wrong error:%v", err)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	failed := errors.New("failed")
+	for _, pool := range []*Pool{nil, mustPool(t, 4)} {
+		group := NewGroup(context.Background(), pool, false)
+		var count int32
+		for i := 0; i < 20; i++ {
+			i := i
+			_ = group.Go(func(ctx context.Context) error {
+				atomic.AddInt32(&count, 1)
+				if i == 5 {
+					return failed
+				}
+				return nil
+			})
+		}
+		if err := group.Wait(); err != failed {
+			t.Fatalf("Wrong error:%v", err)
+		}
+		// 未开启出错取消时所有任务都会执行
+		if count != 20 {
+			t.Fatalf("Wrong count:%d", count)
+		}
+		if pool != nil {
+			pool.StopWait()
+		}
+	}
+
+	group := NewGroup(context.Background(), nil, true)
+	_ = group.Go(func(ctx context.Context) error {
+		return failed
+	})
+	_ = group.Go(func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return errors.New("not canceled")
+		}
+	})
+	if err := group.Wait(); err != failed {
+		t.Fatalf("Wrong error:%v", err)
+	}
+}
+
+func mustPool(t *testing.T, size int) *Pool {
+	pool, err := NewWithOptions(Options{Size: size})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pool
+}
+
+func TestMapStreamReduce(t *testing.T) {
+	inputs := make(chan interface{})
+	go func() {
+		for i := 1; i <= 100; i++ {
+			inputs <- i
+		}
+		close(inputs)
+	}()
+	outputs, err := MapStream(context.Background(), inputs, func(ctx context.Context, input interface{}) (interface{}, error) {
+		return input.(int) * 2, nil
+	}, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, err := Reduce(outputs, 0, func(acc interface{}, value interface{}) (interface{}, error) {
+		return acc.(int) + value.(int), nil
+	})
+	if err != nil || sum.(int) != 10100 {
+		t.Fatalf("Wrong sum:%v, err:%v", sum, err)
+	}
+
+	failed := errors.New("failed")
+	inputs = make(chan interface{}, 10)
+	for i := 0; i < 10; i++ {
+		inputs <- i
+	}
+	close(inputs)
+	outputs, _ = MapStream(context.Background(), inputs, func(ctx context.Context, input interface{}) (interface{}, error) {
+		if input.(int) == 5 {
+			return nil, failed
+		}
+		return input, nil
+	}, 2)
+	if _, err = Reduce(outputs, 0, func(acc interface{}, value interface{}) (interface{}, error) {
+		return acc.(int) + value.(int), nil
+	}); err != failed {
+		t.Fatalf("Wrong error:%v", err)
+	}
+}
+
+func TestCollectResults(t *testing.T) {
+	pool, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 100; i > 0; i-- {
+		if err = pool.SubmitJob(context.Background(), &Job{Id: i, Number: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	go pool.StopWait()
+	results := CollectResults(pool.Results())
+	if len(results) != 100 {
+		t.Fatalf("Wrong count:%d", len(results))
+	}
+	for i, result := range results {
+		if result.Job.Id != i+1 || result.Sum != DigitSum(i+1) {
+			t.Fatalf("Wrong result at %d:%+v", i, result)
+		}
+	}
+
+	pool, err = New(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 10; i++ {
+		if err = pool.SubmitJob(context.Background(), &Job{Id: i, Number: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	go pool.StopWait()
+	total, err := ReduceResults(pool.Results(), 0, func(acc interface{}, result *Result) (interface{}, error) {
+		return acc.(int) + result.Sum, nil
+	})
+	if err != nil || total.(int) != 46 {
+		t.Fatalf("Wrong total:%v, err:%v", total, err)
+	}
+}
@@ -0,0 +1,298 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// 漏桶排队已满
+var ErrLimitExceeded = errors.New("Rate limit exceeded")
+
+// 限流器，rate 为每秒允许的次数，可在运行时修改
+type Limiter interface {
+	// 阻塞直到允许执行或 ctx 结束
+	Wait(ctx context.Context) error
+	// 非阻塞预约一次执行：ok 为 true 时已占用名额，等待 wait 后执行；
+	// ok 为 false 时暂时无法预约（如速率为 0），等待 wait 后重新预约
+	Reserve() (wait time.Duration, ok bool, err error)
+	SetRate(rate float64)
+}
+
+/*
+令牌桶：
+按 rate 匀速生成令牌，最多积攒 burst 个，
+空闲一段时间后允许突发执行 burst 次，之后按 rate 限速
+*/
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+// 创建令牌桶，初始为满桶
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// 按经过的时间补充令牌，调用方持有锁
+func (b *TokenBucket) refill(now time.Time) {
+	if b.rate > 0 {
+		b.tokens = math.Min(float64(b.burst), b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+}
+
+// 非阻塞获取令牌，失败时返回需要等待的时间
+func (b *TokenBucket) reserve() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	if b.rate <= 0 {
+		// 速率为 0 时暂停，定期检查速率是否恢复
+		return time.Second, false
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second)), false
+}
+
+// 获取一个令牌
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	for {
+		wait, ok := b.reserve()
+		if ok {
+			return nil
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// 预约令牌，令牌不足时预支，按欠下的令牌数计算等待时间
+func (b *TokenBucket) Reserve() (time.Duration, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	if b.rate <= 0 && b.tokens < 1 {
+		return time.Second, false, nil
+	}
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0, true, nil
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second)), true, nil
+}
+
+// 修改生成速率
+func (b *TokenBucket) SetRate(rate float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	b.rate = rate
+}
+
+// 修改桶容量
+func (b *TokenBucket) SetBurst(burst int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if burst < 1 {
+		burst = 1
+	}
+	b.refill(time.Now())
+	b.burst = burst
+	b.tokens = math.Min(b.tokens, float64(burst))
+}
+
+/*
+漏桶：
+请求按 1/rate 的固定间隔依次放行，不允许突发，
+最多 capacity 个请求排队等待，超出时立即返回 ErrLimitExceeded
+*/
+type LeakyBucket struct {
+	mu       sync.Mutex
+	rate     float64
+	capacity int
+	next     time.Time
+}
+
+// 创建漏桶，capacity 小于等于 0 时不限制排队数量
+func NewLeakyBucket(rate float64, capacity int) *LeakyBucket {
+	return &LeakyBucket{
+		rate:     rate,
+		capacity: capacity,
+	}
+}
+
+// 预约放行时间
+func (b *LeakyBucket) reserve() (time.Duration, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rate <= 0 {
+		return time.Second, nil
+	}
+	now := time.Now()
+	interval := time.Duration(float64(time.Second) / b.rate)
+	at := b.next
+	if at.Before(now) {
+		at = now
+	}
+	wait := at.Sub(now)
+	if b.capacity > 0 && wait > time.Duration(b.capacity)*interval {
+		return 0, ErrLimitExceeded
+	}
+	b.next = at.Add(interval)
+	return wait, nil
+}
+
+// 等待放行，已预约的时间段在 ctx 结束后不归还
+func (b *LeakyBucket) Wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		paused := b.rate <= 0
+		b.mu.Unlock()
+		if !paused {
+			break
+		}
+		if err := sleep(ctx, time.Second); err != nil {
+			return err
+		}
+	}
+	wait, err := b.reserve()
+	if err != nil {
+		return err
+	}
+	return sleep(ctx, wait)
+}
+
+// 预约放行时间，排队已满时返回 ErrLimitExceeded
+func (b *LeakyBucket) Reserve() (time.Duration, bool, error) {
+	b.mu.Lock()
+	paused := b.rate <= 0
+	b.mu.Unlock()
+	if paused {
+		return time.Second, false, nil
+	}
+	wait, err := b.reserve()
+	return wait, err == nil, err
+}
+
+// 修改放行速率，对之后的请求生效
+func (b *LeakyBucket) SetRate(rate float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rate = rate
+}
+
+// 按 key 限流器默认的空闲回收时间
+const KeyIdleTimeout = 10 * time.Minute
+
+/*
+按 key 分别限流，如按下游主机限制 QPS，
+超过空闲回收时间未使用的 key 被删除，避免 key 只增不减，下次使用时重新创建
+*/
+type KeyedLimiter struct {
+	mu          sync.Mutex
+	factory     func(key string) Limiter
+	limiters    map[string]*keyedLimiter
+	idleTimeout time.Duration
+	swept       time.Time
+}
+
+// key 对应的限流器与最后使用时间
+type keyedLimiter struct {
+	limiter Limiter
+	used    time.Time
+}
+
+// 创建按 key 限流器，首次使用某个 key 时调用 factory 创建限流器，空闲回收时间默认 KeyIdleTimeout
+func NewKeyedLimiter(factory func(key string) Limiter) *KeyedLimiter {
+	return &KeyedLimiter{
+		factory:     factory,
+		limiters:    make(map[string]*keyedLimiter),
+		idleTimeout: KeyIdleTimeout,
+		swept:       time.Now(),
+	}
+}
+
+// 修改空闲回收时间，被回收的 key 通过 SetRate 修改的速率不会保留
+func (k *KeyedLimiter) SetIdleTimeout(timeout time.Duration) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.idleTimeout = timeout
+}
+
+// key 对应的限流器
+func (k *KeyedLimiter) Get(key string) Limiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	now := time.Now()
+	// 每个回收周期最多遍历一次
+	if k.idleTimeout > 0 && now.Sub(k.swept) >= k.idleTimeout {
+		for name, entry := range k.limiters {
+			if now.Sub(entry.used) >= k.idleTimeout {
+				delete(k.limiters, name)
+			}
+		}
+		k.swept = now
+	}
+	entry, ok := k.limiters[key]
+	if !ok {
+		entry = &keyedLimiter{limiter: k.factory(key)}
+		k.limiters[key] = entry
+	}
+	entry.used = now
+	return entry.limiter
+}
+
+// 当前保留的 key 数量
+func (k *KeyedLimiter) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.limiters)
+}
+
+// 等待 key 对应的限流器
+func (k *KeyedLimiter) Wait(ctx context.Context, key string) error {
+	return k.Get(key).Wait(ctx)
+}
+
+// 预约 key 对应的限流器
+func (k *KeyedLimiter) Reserve(key string) (time.Duration, bool, error) {
+	return k.Get(key).Reserve()
+}
+
+// 修改 key 对应的速率
+func (k *KeyedLimiter) SetRate(key string, rate float64) {
+	k.Get(key).SetRate(rate)
+}
+
+// 等待指定时间，ctx 结束时提前返回
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,260 @@
+package workpool
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	bucket := NewTokenBucket(50, 5)
+	ctx := context.Background()
+	start := time.Now()
+	// 满桶可以突发执行 burst 次
+	for i := 0; i < 5; i++ {
+		if err := bucket.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Millisecond {
+		t.Fatalf("Burst should not wait, elapsed:%v", elapsed)
+	}
+	// 之后按 50/s 限速，5 次约 100ms
+	for i := 0; i < 5; i++ {
+		if err := bucket.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Fatalf("Rate not limited, elapsed:%v", elapsed)
+	}
+
+	// 速率为 0 时暂停直到 ctx 结束
+	bucket.SetRate(0)
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := bucket.Wait(timeout); err != context.DeadlineExceeded {
+		t.Fatalf("Paused bucket should wait, err:%v", err)
+	}
+	bucket.SetRate(1000)
+	if err := bucket.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLeakyBucket(t *testing.T) {
+	bucket := NewLeakyBucket(100, 3)
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := bucket.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 第一次立即放行，之后每 10ms 放行一次
+	if elapsed := time.Since(start); elapsed < 25*time.Millisecond {
+		t.Fatalf("Leaky bucket should not burst, elapsed:%v", elapsed)
+	}
+
+	// 并发请求超过排队容量时直接拒绝
+	bucket = NewLeakyBucket(10, 2)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		rejected int
+	)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := bucket.Wait(ctx); err == ErrLimitExceeded {
+				mu.Lock()
+				rejected++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if rejected != 2 {
+		t.Fatalf("Wrong rejected, expect:2, got:%d", rejected)
+	}
+}
+
+func TestPoolKeyLimiter(t *testing.T) {
+	limiter := NewKeyedLimiter(func(key string) Limiter {
+		return NewTokenBucket(20, 1)
+	})
+	pool, err := NewWithOptions(Options{Size: 8, KeyLimiter: limiter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Stop()
+
+	var (
+		mu    sync.Mutex
+		times = make(map[string][]time.Time)
+		jobs  []*Job
+	)
+	for i := 0; i < 6; i++ {
+		key := "a.example.com"
+		if i%2 == 1 {
+			key = "b.example.com"
+		}
+		job := &Job{
+			RateKey: key,
+			Task: func(ctx context.Context) error {
+				mu.Lock()
+				times[key] = append(times[key], time.Now())
+				mu.Unlock()
+				return nil
+			},
+		}
+		if err = pool.SubmitJob(context.Background(), job); err != nil {
+			t.Fatal(err)
+		}
+		jobs = append(jobs, job)
+	}
+	for _, job := range jobs {
+		if err = job.Wait(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 每个 key 单独按 20/s 限速，3 次至少间隔约 100ms
+	for key, list := range times {
+		if len(list) != 3 {
+			t.Fatalf("Wrong count for %s:%d", key, len(list))
+		}
+		first, last := list[0], list[0]
+		for _, at := range list {
+			if at.Before(first) {
+				first = at
+			}
+			if at.After(last) {
+				last = at
+			}
+		}
+		if last.Sub(first) < 80*time.Millisecond {
+			t.Fatalf("Key %s not limited, elapsed:%v", key, last.Sub(first))
+		}
+	}
+}
+
+func TestPoolKeyLimiterNoBlocking(t *testing.T) {
+	limiter := NewKeyedLimiter(func(key string) Limiter {
+		if key == "slow" {
+			return NewTokenBucket(2, 1)
+		}
+		return NewTokenBucket(1000, 100)
+	})
+	pool, err := NewWithOptions(Options{Size: 1, KeyLimiter: limiter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Stop()
+
+	submit := func(key string) *Job {
+		job := &Job{
+			RateKey: key,
+			Task:    func(ctx context.Context) error { return nil },
+		}
+		if err := pool.SubmitJob(context.Background(), job); err != nil {
+			t.Fatal(err)
+		}
+		return job
+	}
+	start := time.Now()
+	var slow, fast []*Job
+	for i := 0; i < 3; i++ {
+		slow = append(slow, submit("slow"))
+	}
+	for i := 0; i < 5; i++ {
+		fast = append(fast, submit("fast"))
+	}
+	// 被限流的任务重新排队，唯一的协程继续执行其他 key 的任务
+	for _, job := range fast {
+		if err = job.Wait(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 300*time.Millisecond {
+		t.Fatalf("Fast key blocked by slow key, elapsed:%v", elapsed)
+	}
+	for _, job := range slow {
+		if err = job.Wait(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// slow 按 2/s 限速，3 次至少约 1s
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Fatalf("Slow key not limited, elapsed:%v", elapsed)
+	}
+	// 限流等待不计入执行耗时
+	if exec := pool.Stats().Exec; exec.Count != 8 || exec.Sum > 0.1 {
+		t.Fatalf("Wrong exec latency, count:%d, sum:%v", exec.Count, exec.Sum)
+	}
+}
+
+func TestPoolLimiterHeldJob(t *testing.T) {
+	limiter := NewKeyedLimiter(func(key string) Limiter {
+		return NewTokenBucket(10, 1)
+	})
+	pool, err := NewWithOptions(Options{Size: 1, KeyLimiter: limiter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Stop()
+
+	start := time.Now()
+	var started time.Duration
+	var slow []*Job
+	for i := 0; i < 2; i++ {
+		job := &Job{
+			RateKey: "slow",
+			Task: func(ctx context.Context) error {
+				started = time.Since(start)
+				return nil
+			},
+		}
+		if err = pool.SubmitJob(context.Background(), job); err != nil {
+			t.Fatal(err)
+		}
+		slow = append(slow, job)
+	}
+	// 积压的任务共需约 400ms
+	for i := 0; i < 20; i++ {
+		if _, err = pool.Submit(context.Background(), func(ctx context.Context) error {
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, job := range slow {
+		if err = job.Wait(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 第二个任务预约在 100ms 后，到时间直接执行，不排在积压任务之后
+	if started < 80*time.Millisecond || started > 250*time.Millisecond {
+		t.Fatalf("Held job should start at its reserved time, started:%v", started)
+	}
+}
+
+func TestKeyedLimiterIdleTimeout(t *testing.T) {
+	limiter := NewKeyedLimiter(func(key string) Limiter {
+		return NewTokenBucket(1, 1)
+	})
+	limiter.SetIdleTimeout(20 * time.Millisecond)
+	limiter.Get("a")
+	limiter.Get("b")
+	if limiter.Len() != 2 {
+		t.Fatalf("Wrong keys:%d", limiter.Len())
+	}
+	time.Sleep(30 * time.Millisecond)
+	// 空闲超时的 key 被回收，新的 key 正常创建
+	limiter.Get("c")
+	if limiter.Len() != 1 {
+		t.Fatalf("Idle keys not evicted, keys:%d", limiter.Len())
+	}
+}
@@ -0,0 +1,26 @@
+package workpool
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// 任务 panic 转换的错误，附带 panic 时的调用栈
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("Job panic: %v\n%s", e.Value, e.Stack)
+}
+
+// 执行函数并捕获 panic，避免单个任务导致整个进程退出
+func protect(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
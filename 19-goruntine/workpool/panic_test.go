@@ -0,0 +1,56 @@
+package workpool
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPoolPanic(t *testing.T) {
+	pool, err := NewWithOptions(Options{Size: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Stop()
+
+	job, _ := pool.Submit(context.Background(), func(ctx context.Context) error {
+		var m map[string]int
+		m["boom"] = 1
+		return nil
+	})
+	err = job.Wait()
+	panicErr, ok := err.(*PanicError)
+	if !ok {
+		t.Fatalf("Panic should be returned as PanicError, err:%v", err)
+	}
+	if !strings.Contains(string(panicErr.Stack), "panic_test.go") {
+		t.Fatalf("Stack not attached:%s", panicErr.Stack)
+	}
+	if pool.Panics() != 1 {
+		t.Fatalf("Wrong panic count:%d", pool.Panics())
+	}
+
+	// 协程继续处理后续任务
+	next, _ := pool.Submit(context.Background(), func(ctx context.Context) error {
+		return nil
+	})
+	if err = next.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkerPanic(t *testing.T) {
+	jobChan := make(chan *Job, 2)
+	retChan := make(chan *Result, 2)
+	go Worker(jobChan, retChan)
+	jobChan <- nil
+	jobChan <- &Job{Id: 2, Number: 224}
+	close(jobChan)
+
+	if ret := <-retChan; ret.Err == nil {
+		t.Fatal("Nil job should panic")
+	}
+	if ret := <-retChan; ret.Sum != 8 {
+		t.Fatalf("Wrong sum:%d", ret.Sum)
+	}
+}
@@ -0,0 +1,801 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// 默认任务队列长度
+	QueueSize = 1000
+	// 自动扩缩容时协程的默认空闲回收时间
+	IdleTimeout = time.Minute
+	// 预写日志默认压缩间隔
+	CompactInterval = time.Minute
+)
+
+// 扩缩容原因
+const (
+	ScaleBacklog = "backlog"
+	ScaleWait    = "wait"
+	ScaleIdle    = "idle"
+)
+
+var (
+	// 协程池已停止
+	ErrStopped = errors.New("Workpool stopped")
+	// 协程池参数错误
+	ErrInvalidSize = errors.New("Workpool size must be positive")
+	// 扩缩容参数错误
+	ErrInvalidScale = errors.New("Workpool workers must satisfy 0 <= MinWorkers <= MaxWorkers")
+)
+
+// 通用任务函数，ctx 在任务取消、超时或协程池停止时结束，任务需要在 ctx 结束后尽快返回
+type Task func(ctx context.Context) error
+
+// 具名任务的处理函数，payload 为 Job.Payload
+type Handler func(ctx context.Context, payload []byte) error
+
+// 扩缩容事件
+type ScaleEvent struct {
+	// 变化后的协程数量
+	Workers int
+	// 增加为 1，回收为 -1
+	Delta  int
+	Reason string
+}
+
+// 协程池参数
+type Options struct {
+	// 协程数量，自动扩缩容时为初始数量，默认 MinWorkers
+	Size int
+	// 大于 0 时开启自动扩缩容，协程数量在 MinWorkers 与 MaxWorkers 之间变化
+	MinWorkers int
+	MaxWorkers int
+	// 提交任务时队列积压超过该值则增加协程，默认 1
+	ScaleBacklog int
+	// 任务排队时间超过该值则增加协程，为 0 时不检查
+	ScaleWait time.Duration
+	// 协程空闲超过该时间则回收，默认 IdleTimeout
+	IdleTimeout time.Duration
+	// 扩缩容回调
+	OnScale func(event ScaleEvent)
+	// 任务队列长度，默认 QueueSize
+	QueueSize int
+	// 是否将结果写入 Results 管道，开启后调用方需要持续消费，否则队列满后协程会阻塞；
+	// 停止过程中管道已满时丢弃结果
+	Results bool
+	// 任务默认超时时间，Job.Timeout 优先，为 0 时不限制
+	Timeout time.Duration
+	// 任务默认重试策略，Job.Retry 优先
+	Retry *RetryPolicy
+	// 死信回调，任务最终失败时调用
+	OnDeadLetter func(letter *DeadLetter)
+	// 按 Job.Priority 调度，优先级高的任务先执行
+	Priority bool
+	// 按 Job.Tenant 公平调度，同优先级的租户之间轮询，开启后同样按优先级调度
+	FairShare bool
+	// 任务每等待该时长优先级提升一级，避免低优先级任务饿死，为 0 时不提升
+	AgingInterval time.Duration
+	// 具名任务的处理函数，按 Job.Name 查找
+	Handlers map[string]Handler
+	// 预写日志路径，设置后具名任务写入日志后才确认提交，重启时恢复未完成的任务
+	WALPath string
+	// 预写日志压缩间隔，默认 CompactInterval
+	CompactInterval time.Duration
+	// 耗时直方图分桶上限（秒），默认 DefaultBuckets
+	LatencyBuckets []float64
+	// 全局限流，每次执行前预约，需要等待时任务到预约时间后再执行，不占用协程
+	Limiter Limiter
+	// 按 Job.RateKey 限流，与全局限流同时生效
+	KeyLimiter *KeyedLimiter
+}
+
+/*
+协程池：
+任务提交到 jobChan，协程消费并执行，结果写入 retChan，
+协程数量固定为 Size，或在 MinWorkers 与 MaxWorkers 之间按积压情况自动扩缩容，
+失败重试的任务在定时器到期后重新入队，被限流的任务到预约时间后直接交给空闲协程，等待期间不占用协程，
+Stop 取消正在执行的任务并丢弃队列，StopWait 等待队列中与等待重试的任务全部执行完成
+*/
+type Pool struct {
+	// 原子计数放在首位，保证 32 位平台上 8 字节对齐
+	panics      uint64
+	submitted   uint64
+	completed   uint64
+	failed      uint64
+	running     int64
+	submitting  int64
+	waitLatency *histogram
+	execLatency *histogram
+	options     Options
+	jobChan     chan *Job
+	retChan     chan *Result
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+	jobs        sync.WaitGroup
+	mu          sync.RWMutex
+	closed      bool
+	stopping    chan struct{}
+	queueOnce   sync.Once
+	closeOnce   sync.Once
+	scaleMu     sync.Mutex
+	workers     int
+	queue       *priorityQueue
+	slots       chan struct{}
+	ready       chan struct{}
+	held        chan *Job
+	wal         *wal
+}
+
+// 创建协程池，结果写入 Results 管道
+func New(size int) (*Pool, error) {
+	return NewWithOptions(Options{Size: size, Results: true})
+}
+
+// 按参数创建协程池
+func NewWithOptions(options Options) (*Pool, error) {
+	if options.MaxWorkers > 0 {
+		if options.MinWorkers < 0 || options.MinWorkers > options.MaxWorkers {
+			return nil, ErrInvalidScale
+		}
+		if options.Size < options.MinWorkers {
+			options.Size = options.MinWorkers
+		}
+		if options.Size > options.MaxWorkers {
+			options.Size = options.MaxWorkers
+		}
+		if options.ScaleBacklog <= 0 {
+			options.ScaleBacklog = 1
+		}
+		if options.IdleTimeout <= 0 {
+			options.IdleTimeout = IdleTimeout
+		}
+	} else if options.Size <= 0 {
+		return nil, ErrInvalidSize
+	}
+	if options.QueueSize <= 0 {
+		options.QueueSize = QueueSize
+	}
+	if options.CompactInterval <= 0 {
+		options.CompactInterval = CompactInterval
+	}
+	if len(options.LatencyBuckets) == 0 {
+		options.LatencyBuckets = DefaultBuckets
+	}
+	var (
+		log        *wal
+		unfinished []*walRecord
+	)
+	if options.WALPath != "" {
+		var err error
+		if log, unfinished, err = openWAL(options.WALPath); err != nil {
+			return nil, err
+		}
+		for _, record := range unfinished {
+			if _, ok := options.Handlers[record.Name]; !ok {
+				log.close()
+				return nil, fmt.Errorf("No handler for job[%s] in wal[%s]", record.Name, options.WALPath)
+			}
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		options:  options,
+		ctx:      ctx,
+		cancel:   cancel,
+		workers:  options.Size,
+		held:     make(chan *Job),
+		stopping: make(chan struct{}),
+		wal:      log,
+
+		waitLatency: newHistogram(options.LatencyBuckets),
+		execLatency: newHistogram(options.LatencyBuckets),
+	}
+	if options.Priority || options.FairShare {
+		// 任务在优先级队列中排队，jobChan 不缓冲，出队时才决定执行顺序
+		p.jobChan = make(chan *Job)
+		p.queue = newPriorityQueue(options.AgingInterval, options.FairShare)
+		p.slots = make(chan struct{}, options.QueueSize)
+		p.ready = make(chan struct{})
+		go p.dispatch()
+	} else {
+		p.jobChan = make(chan *Job, options.QueueSize)
+	}
+	if options.Results {
+		p.retChan = make(chan *Result, options.QueueSize)
+	}
+	for i := 0; i < options.Size; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+	if p.wal != nil {
+		go p.replay(unfinished)
+		go p.compact()
+	}
+	return p, nil
+}
+
+// 提交通用任务，队列已满时阻塞直到 ctx 结束
+func (p *Pool) Submit(ctx context.Context, task Task) (*Job, error) {
+	if task == nil {
+		return nil, errors.New("Task is nil")
+	}
+	job := &Job{Task: task}
+	if err := p.SubmitJob(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// 提交具名任务，开启预写日志时写入日志后才返回
+func (p *Pool) SubmitNamed(ctx context.Context, name string, payload []byte) (*Job, error) {
+	job := &Job{Name: name, Payload: payload}
+	if err := p.SubmitJob(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+/*
+提交任务：
+设置 Name 的任务按 Options.Handlers 执行，否则执行 Task，均未设置时按数字求和处理；
+开启预写日志时具名任务先写入日志再入队，入队失败则记为完成
+*/
+func (p *Pool) SubmitJob(ctx context.Context, job *Job) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if job.Name != "" {
+		handler, ok := p.options.Handlers[job.Name]
+		if !ok {
+			return fmt.Errorf("No handler for job[%s]", job.Name)
+		}
+		job.Task = func(ctx context.Context) error {
+			return handler(ctx, job.Payload)
+		}
+	}
+	job.ctx = ctx
+	job.done = make(chan struct{})
+	job.submitted = time.Now()
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrStopped
+	}
+	// 提交过程中不回收最后一个协程，避免任务入队时已没有协程且积压不足以触发扩容
+	atomic.AddInt64(&p.submitting, 1)
+	defer atomic.AddInt64(&p.submitting, -1)
+	logged := false
+	if p.wal != nil && job.Name != "" && job.walId == 0 {
+		id, err := p.wal.submit(job)
+		if err != nil {
+			return err
+		}
+		job.walId, logged = id, true
+	}
+	p.jobs.Add(1)
+	if err := p.enqueue(ctx, job); err != nil {
+		p.jobs.Done()
+		if logged {
+			_ = p.wal.done(job.walId)
+		}
+		return err
+	}
+	atomic.AddUint64(&p.submitted, 1)
+	if p.autoScale() && (p.backlog() >= p.options.ScaleBacklog || p.Workers() == 0) {
+		p.scaleUp(ScaleBacklog)
+	}
+	return nil
+}
+
+// 任务入队，队列已满时阻塞
+func (p *Pool) enqueue(ctx context.Context, job *Job) error {
+	var queue chan<- *Job = p.jobChan
+	var slots chan<- struct{}
+	if p.queue != nil {
+		queue, slots = nil, p.slots
+	}
+	select {
+	case queue <- job:
+	case slots <- struct{}{}:
+		p.queue.push(job)
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.ctx.Done():
+		return ErrStopped
+	}
+	return nil
+}
+
+// 排队中的任务数量
+func (p *Pool) backlog() int {
+	if p.queue != nil {
+		return p.queue.Len()
+	}
+	return len(p.jobChan)
+}
+
+/*
+按优先级从队列取出任务写入 jobChan：
+有空闲协程发出 ready 信号后才出队，保证执行顺序在最后一刻决定，
+队列关闭且为空时关闭 jobChan
+*/
+func (p *Pool) dispatch() {
+	defer close(p.jobChan)
+	for {
+		select {
+		case <-p.ready:
+		case <-p.queue.Drained():
+			return
+		}
+		job, ok := p.queue.pop()
+		if !ok {
+			return
+		}
+		<-p.slots
+		p.jobChan <- job
+	}
+}
+
+// 任务结果管道，协程池停止后关闭；未开启 Options.Results 时返回 nil
+func (p *Pool) Results() <-chan *Result {
+	return p.retChan
+}
+
+// 停止协程池：不再接收任务，取消正在执行与等待重试的任务，队列中的任务以 ErrStopped 结束
+func (p *Pool) Stop() {
+	p.cancel()
+	p.shutdown()
+}
+
+// 停止协程池：不再接收任务，等待队列中、正在执行与等待重试的任务完成
+func (p *Pool) StopWait() {
+	p.shutdown()
+	p.cancel()
+}
+
+// 不再接收任务，等待已提交的任务结束后关闭任务队列并等待协程退出
+func (p *Pool) shutdown() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.stopping)
+	}
+	p.mu.Unlock()
+
+	// 等待重试的任务还会重新入队，全部结束后才能关闭队列
+	p.jobs.Wait()
+	p.queueOnce.Do(func() {
+		if p.queue != nil {
+			p.queue.close()
+		} else {
+			close(p.jobChan)
+		}
+	})
+	p.wg.Wait()
+	p.closeOnce.Do(func() {
+		if p.wal != nil {
+			_ = p.wal.close()
+		}
+		if p.retChan != nil {
+			close(p.retChan)
+		}
+	})
+}
+
+// 重新提交预写日志中未完成的任务，协程池停止后剩余任务留在日志中
+func (p *Pool) replay(records []*walRecord) {
+	for _, record := range records {
+		job := &Job{
+			Name:     record.Name,
+			Payload:  record.Payload,
+			Priority: record.Priority,
+			Tenant:   record.Tenant,
+			RateKey:  record.RateKey,
+			walId:    record.Id,
+		}
+		if err := p.SubmitJob(context.Background(), job); err != nil {
+			return
+		}
+	}
+}
+
+// 定期压缩预写日志，协程池停止时退出
+func (p *Pool) compact() {
+	ticker := time.NewTicker(p.options.CompactInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = p.wal.compact()
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// 预写日志中未完成的任务数量，未开启预写日志时返回 0
+func (p *Pool) Pending() int {
+	if p.wal == nil {
+		return 0
+	}
+	return p.wal.len()
+}
+
+// 当前协程数量
+func (p *Pool) Workers() int {
+	p.scaleMu.Lock()
+	defer p.scaleMu.Unlock()
+	return p.workers
+}
+
+// 是否开启自动扩缩容
+func (p *Pool) autoScale() bool {
+	return p.options.MaxWorkers > 0
+}
+
+// 增加一个协程，已达上限时忽略
+func (p *Pool) scaleUp(reason string) {
+	p.scaleMu.Lock()
+	if p.workers >= p.options.MaxWorkers {
+		p.scaleMu.Unlock()
+		return
+	}
+	p.workers++
+	workers := p.workers
+	p.wg.Add(1)
+	go p.worker()
+	p.scaleMu.Unlock()
+
+	if p.options.OnScale != nil {
+		p.options.OnScale(ScaleEvent{Workers: workers, Delta: 1, Reason: reason})
+	}
+}
+
+// 回收空闲协程，已达下限、队列中仍有任务或正在提交任务时不回收最后一个协程，返回 false
+func (p *Pool) retire() bool {
+	p.scaleMu.Lock()
+	if p.workers <= p.options.MinWorkers || p.backlog() > 0 ||
+		(p.workers == 1 && atomic.LoadInt64(&p.submitting) > 0) {
+		p.scaleMu.Unlock()
+		return false
+	}
+	p.workers--
+	workers := p.workers
+	p.scaleMu.Unlock()
+
+	if p.options.OnScale != nil {
+		p.options.OnScale(ScaleEvent{Workers: workers, Delta: -1, Reason: ScaleIdle})
+	}
+	return true
+}
+
+// 协程退出
+func (p *Pool) exit() {
+	p.scaleMu.Lock()
+	p.workers--
+	p.scaleMu.Unlock()
+	p.wg.Done()
+}
+
+// 协程处理函数，自动扩缩容时空闲超时退出
+func (p *Pool) worker() {
+	var (
+		idle  *time.Timer
+		idleC <-chan time.Time
+	)
+	if p.autoScale() {
+		idle = time.NewTimer(p.options.IdleTimeout)
+		defer idle.Stop()
+		idleC = idle.C
+	}
+	for {
+		var (
+			job *Job
+			ok  bool
+		)
+		// 优先级调度时先发出 ready 信号再领取任务，ready 为 nil 时直接从 jobChan 领取
+		select {
+		case p.ready <- struct{}{}:
+			job, ok = <-p.jobChan
+		case job, ok = <-p.jobChan:
+		case job = <-p.held:
+			ok = true
+		case <-idleC:
+			if p.retire() {
+				p.wg.Done()
+				return
+			}
+			idle.Reset(p.options.IdleTimeout)
+			continue
+		}
+		if !ok {
+			p.exit()
+			return
+		}
+		// 被限流的任务等待预约时间，协程继续领取其他任务
+		if !p.admit(job) {
+			continue
+		}
+
+		wait := time.Since(job.submitted)
+		p.waitLatency.observe(wait)
+		if p.options.ScaleWait > 0 && wait > p.options.ScaleWait {
+			p.scaleUp(ScaleWait)
+		}
+		atomic.AddInt64(&p.running, 1)
+		start := time.Now()
+		result := p.run(job)
+		p.execLatency.observe(time.Since(start))
+		atomic.AddInt64(&p.running, -1)
+		if result != nil {
+			p.finish(job, result)
+		}
+		if idle != nil {
+			if !idle.Stop() {
+				<-idle.C
+			}
+			idle.Reset(p.options.IdleTimeout)
+		}
+	}
+}
+
+/*
+执行一次任务：
+失败且可以重试时由定时器在退避时间后重新入队并返回 nil，不占用当前协程，
+重试耗尽或不可重试时进入死信
+*/
+func (p *Pool) run(job *Job) *Result {
+	result := &Result{Job: job}
+	if p.ctx.Err() != nil {
+		result.Err = ErrStopped
+		return result
+	}
+	if err := job.ctx.Err(); err != nil {
+		result.Err = err
+		return result
+	}
+	if job.Task == nil {
+		result.Sum = DigitSum(job.Number)
+		return result
+	}
+
+	ctx, cancel := mergeContext(job.ctx, p.ctx)
+	defer cancel()
+	job.attempts++
+	// 预约限流失败时不执行任务，按本次执行失败处理
+	err := job.limitErr
+	job.limitErr = nil
+	if err == nil {
+		err = p.call(ctx, job)
+	}
+	if err == nil {
+		return result
+	}
+	job.errs = append(job.errs, err)
+	result.Err = err
+	if ctx.Err() != nil {
+		// 调用方取消或协程池停止，不进入死信
+		return result
+	}
+	retry := job.Retry
+	if retry == nil {
+		retry = p.options.Retry
+	}
+	if retry.shouldRetry(job.attempts, err) {
+		go p.retry(job, result, retry.Backoff(job.attempts))
+		return nil
+	}
+
+	if p.options.OnDeadLetter != nil {
+		p.options.OnDeadLetter(&DeadLetter{Job: job, Errors: job.Errors()})
+	}
+	return result
+}
+
+// 退避时间后重新入队，调用方取消或协程池停止时以最后一次的错误结束
+func (p *Pool) retry(job *Job, result *Result, backoff time.Duration) {
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-job.ctx.Done():
+		p.finish(job, result)
+		return
+	case <-p.ctx.Done():
+		p.finish(job, result)
+		return
+	}
+
+	atomic.AddInt64(&p.submitting, 1)
+	defer atomic.AddInt64(&p.submitting, -1)
+	job.submitted = time.Now()
+	if err := p.enqueue(job.ctx, job); err != nil {
+		p.finish(job, result)
+		return
+	}
+	if p.autoScale() && (p.backlog() >= p.options.ScaleBacklog || p.Workers() == 0) {
+		p.scaleUp(ScaleBacklog)
+	}
+}
+
+/*
+被限流的任务等到预约时间后直接交给下一个空闲协程，不再重新排队：
+排队积压不会推迟已预约的任务，也不会打乱队列中其他任务的顺序
+*/
+func (p *Pool) hold(job *Job, delay time.Duration) {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-job.ctx.Done():
+		p.finish(job, &Result{Job: job, Err: job.ctx.Err()})
+		return
+	case <-p.ctx.Done():
+		p.finish(job, &Result{Job: job, Err: ErrStopped})
+		return
+	}
+
+	// 交给协程前不回收最后一个协程，已没有协程时增加
+	atomic.AddInt64(&p.submitting, 1)
+	defer atomic.AddInt64(&p.submitting, -1)
+	if p.autoScale() && p.Workers() == 0 {
+		p.scaleUp(ScaleBacklog)
+	}
+	job.submitted = time.Now()
+	select {
+	case p.held <- job:
+	case <-job.ctx.Done():
+		p.finish(job, &Result{Job: job, Err: job.ctx.Err()})
+	case <-p.ctx.Done():
+		p.finish(job, &Result{Job: job, Err: ErrStopped})
+	}
+}
+
+// 任务需要的限流器，全局限流在前
+func (p *Pool) limiters(job *Job) []Limiter {
+	var limiters []Limiter
+	if p.options.Limiter != nil {
+		limiters = append(limiters, p.options.Limiter)
+	}
+	if p.options.KeyLimiter != nil && job.RateKey != "" {
+		limiters = append(limiters, p.options.KeyLimiter.Get(job.RateKey))
+	}
+	return limiters
+}
+
+/*
+执行前按全局与 Job.RateKey 预约限流：
+需要等待时返回 false，任务到预约时间后直接交给空闲协程，不占用当前协程，也不阻塞其他 key 的任务，
+已预约的限流器不再重复预约
+*/
+func (p *Pool) admit(job *Job) bool {
+	if job.Task == nil || p.ctx.Err() != nil || job.ctx.Err() != nil {
+		return true
+	}
+	limiters := p.limiters(job)
+	var delay time.Duration
+	for ; job.limits < len(limiters); job.limits++ {
+		wait, ok, err := limiters[job.limits].Reserve()
+		if err != nil {
+			job.limits, job.limitErr = 0, err
+			return true
+		}
+		if !ok {
+			go p.hold(job, wait)
+			return false
+		}
+		if wait > delay {
+			delay = wait
+		}
+	}
+	if delay > 0 {
+		go p.hold(job, delay)
+		return false
+	}
+	job.limits = 0
+	return true
+}
+
+/*
+单次执行任务：
+设置了超时时间时在单独的协程中执行，超时后以 context.DeadlineExceeded 结束并释放当前协程，
+不响应 ctx 的任务在返回前会继续运行，但不再占用协程池的协程
+*/
+func (p *Pool) call(ctx context.Context, job *Job) error {
+	timeout := job.Timeout
+	if timeout <= 0 {
+		timeout = p.options.Timeout
+	}
+	if timeout <= 0 {
+		return p.protect(ctx, job.Task)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- p.protect(ctx, job.Task)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+	// 任务恰好在超时时返回则使用任务的结果
+	select {
+	case err := <-done:
+		return err
+	default:
+		return ctx.Err()
+	}
+}
+
+// 执行任务并捕获 panic，协程继续处理后续任务
+func (p *Pool) protect(ctx context.Context, task Task) error {
+	err := protect(func() error {
+		return task(ctx)
+	})
+	if _, ok := err.(*PanicError); ok {
+		atomic.AddUint64(&p.panics, 1)
+	}
+	return err
+}
+
+// 任务 panic 的次数
+func (p *Pool) Panics() uint64 {
+	return atomic.LoadUint64(&p.panics)
+}
+
+/*
+记录任务结果并通知等待方：
+具名任务执行完成或最终失败后在预写日志中记为完成，
+因协程池 Stop 而中断的任务不记录，重启后重新执行
+*/
+func (p *Pool) finish(job *Job, result *Result) {
+	if job.walId != 0 && !(result.Err != nil && p.ctx.Err() != nil) {
+		// 写入失败时任务会在重启后重新执行，保证至少执行一次
+		_ = p.wal.done(job.walId)
+	}
+	if result.Err != nil {
+		atomic.AddUint64(&p.failed, 1)
+	} else {
+		atomic.AddUint64(&p.completed, 1)
+	}
+	job.err = result.Err
+	close(job.done)
+	if p.retChan != nil {
+		select {
+		case p.retChan <- result:
+		case <-p.stopping:
+			// 停止后调用方可能不再读取，管道已满时丢弃结果，避免停止时阻塞
+			select {
+			case p.retChan <- result:
+			default:
+			}
+		}
+	}
+	p.jobs.Done()
+}
+
+// 合并两个 context，任意一个结束时取消
+func mergeContext(ctx context.Context, other context.Context) (context.Context, context.CancelFunc) {
+	merged, cancel := context.WithCancel(ctx)
+	if other.Done() == nil {
+		return merged, cancel
+	}
+	go func() {
+		select {
+		case <-other.Done():
+			cancel()
+		case <-merged.Done():
+		}
+	}()
+	return merged, cancel
+}
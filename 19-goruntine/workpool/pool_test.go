@@ -0,0 +1,217 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolSubmit(t *testing.T) {
+	pool, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count int32
+	failed := errors.New("failed")
+	for i := 0; i < 100; i++ {
+		i := i
+		_, err = pool.Submit(context.Background(), func(ctx context.Context) error {
+			atomic.AddInt32(&count, 1)
+			if i%10 == 0 {
+				return failed
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Submit failed, err:%v", err)
+		}
+	}
+	var results, errs int
+	done := make(chan struct{})
+	go func() {
+		for ret := range pool.Results() {
+			results++
+			if ret.Err != nil {
+				errs++
+			}
+		}
+		close(done)
+	}()
+	pool.StopWait()
+	<-done
+	if count != 100 || results != 100 || errs != 10 {
+		t.Fatalf("Wrong result, count:%d, results:%d, errs:%d", count, results, errs)
+	}
+	if _, err = pool.Submit(context.Background(), func(ctx context.Context) error { return nil }); err != ErrStopped {
+		t.Fatalf("Submit after stop should fail, err:%v", err)
+	}
+}
+
+func TestPoolDigitSum(t *testing.T) {
+	pool, err := NewWithOptions(Options{Size: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Stop()
+	job := &Job{Id: 1, Number: 224}
+	if err = pool.SubmitJob(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+	if err = job.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if pool.Results() != nil {
+		t.Fatal("Results should be disabled")
+	}
+}
+
+func TestPoolStop(t *testing.T) {
+	pool, err := NewWithOptions(Options{Size: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	running, _ := pool.Submit(context.Background(), func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	queued, _ := pool.Submit(context.Background(), func(ctx context.Context) error {
+		return nil
+	})
+	<-started
+
+	stopped := make(chan struct{})
+	go func() {
+		pool.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop blocked by running job")
+	}
+	if err = running.Wait(); err != context.Canceled {
+		t.Fatalf("Running job should be canceled, err:%v", err)
+	}
+	if err = queued.Wait(); err != ErrStopped {
+		t.Fatalf("Queued job should be stopped, err:%v", err)
+	}
+}
+
+func TestPoolStopWithoutReader(t *testing.T) {
+	for _, stop := range []func(p *Pool){(*Pool).Stop, (*Pool).StopWait} {
+		pool, err := NewWithOptions(Options{Size: 1, QueueSize: 2, Results: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 2; i++ {
+			if _, err = pool.Submit(context.Background(), func(ctx context.Context) error {
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// 结果数量超过管道容量且没有读取方时，停止不会阻塞
+		stopped := make(chan struct{})
+		go func() {
+			for i := 0; i < 3; i++ {
+				_, _ = pool.Submit(context.Background(), func(ctx context.Context) error {
+					return nil
+				})
+			}
+			stop(pool)
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(3 * time.Second):
+			t.Fatal("Stop blocked on unread results")
+		}
+	}
+}
+
+func TestPoolAutoScale(t *testing.T) {
+	var ups, downs int32
+	pool, err := NewWithOptions(Options{
+		MinWorkers:  1,
+		MaxWorkers:  4,
+		IdleTimeout: 20 * time.Millisecond,
+		OnScale: func(event ScaleEvent) {
+			if event.Delta > 0 {
+				atomic.AddInt32(&ups, 1)
+			} else {
+				atomic.AddInt32(&downs, 1)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Stop()
+	if pool.Workers() != 1 {
+		t.Fatalf("Wrong initial workers:%d", pool.Workers())
+	}
+
+	release := make(chan struct{})
+	var jobs []*Job
+	for i := 0; i < 8; i++ {
+		job, err := pool.Submit(context.Background(), func(ctx context.Context) error {
+			<-release
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		jobs = append(jobs, job)
+	}
+	if pool.Workers() != 4 {
+		t.Fatalf("Pool should scale up to max, workers:%d", pool.Workers())
+	}
+	close(release)
+	for _, job := range jobs {
+		_ = job.Wait()
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for pool.Workers() > 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if pool.Workers() != 1 || atomic.LoadInt32(&ups) != 3 || atomic.LoadInt32(&downs) != 3 {
+		t.Fatalf("Pool should scale down to min, workers:%d, ups:%d, downs:%d", pool.Workers(), ups, downs)
+	}
+
+	if _, err = NewWithOptions(Options{MinWorkers: 5, MaxWorkers: 2}); err != ErrInvalidScale {
+		t.Fatalf("Invalid scale options should fail, err:%v", err)
+	}
+}
+
+func TestPoolScaleFromZero(t *testing.T) {
+	pool, err := NewWithOptions(Options{
+		MinWorkers:   0,
+		MaxWorkers:   2,
+		ScaleBacklog: 4,
+		IdleTimeout:  time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Stop()
+	// 提交与最后一个协程回收交替发生，任务都能执行
+	for i := 0; i < 200; i++ {
+		job, err := pool.Submit(context.Background(), func(ctx context.Context) error {
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-job.Done():
+		case <-time.After(time.Second):
+			t.Fatalf("Job %d stuck with %d workers", i, pool.Workers())
+		}
+		time.Sleep(time.Duration(i%3) * time.Millisecond)
+	}
+}
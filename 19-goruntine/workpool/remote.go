@@ -0,0 +1,92 @@
+package workpool
+
+import (
+	"bufio"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// 网络模式编码方式
+const (
+	CodecJSON = "json"
+	CodecGob  = "gob"
+)
+
+const (
+	// 默认心跳间隔
+	HeartbeatInterval = time.Second
+	// 默认心跳超时，超时未收到消息时视为对方已断开
+	HeartbeatTimeout = 3 * time.Second
+)
+
+// 消息类型
+const (
+	msgRegister  = "register"
+	msgJob       = "job"
+	msgResult    = "result"
+	msgHeartbeat = "heartbeat"
+)
+
+// 调度器与远程协程之间传输的消息
+type message struct {
+	Type string
+	// 注册时的远程协程标识与并发数
+	WorkerId string
+	Capacity int
+	// 任务与结果
+	JobId   uint64
+	Name    string
+	Number  int
+	Payload []byte
+	Sum     int
+	Err     string
+}
+
+type encoder interface {
+	Encode(v interface{}) error
+}
+
+type decoder interface {
+	Decode(v interface{}) error
+}
+
+// 按名称创建编码器
+func newEncoder(codec string, w io.Writer) (encoder, error) {
+	switch codec {
+	case CodecJSON:
+		return json.NewEncoder(w), nil
+	case CodecGob:
+		return gob.NewEncoder(w), nil
+	}
+	return nil, fmt.Errorf("Unsupported codec:%s", codec)
+}
+
+// 按名称创建解码器
+func newDecoder(codec string, r io.Reader) (decoder, error) {
+	switch codec {
+	case CodecJSON:
+		return json.NewDecoder(r), nil
+	case CodecGob:
+		return gob.NewDecoder(r), nil
+	}
+	return nil, fmt.Errorf("Unsupported codec:%s", codec)
+}
+
+// 连接建立后远程协程先发送一行编码名称
+func writeHandshake(w io.Writer, codec string) error {
+	_, err := io.WriteString(w, codec+"\n")
+	return err
+}
+
+// 读取编码名称，之后的数据需继续使用同一个 reader 读取
+func readHandshake(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,156 @@
+package workpool
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// 远程协程参数
+type RemoteOptions struct {
+	// 远程协程标识，默认 主机名:进程号
+	Id string
+	// 并发执行的任务数，默认 1
+	Capacity int
+	// 编码方式，CodecJSON 或 CodecGob，默认 CodecJSON
+	Codec string
+	// 具名任务的处理函数
+	Handlers map[string]Handler
+	// 心跳间隔，默认 HeartbeatInterval，需小于调度器的心跳超时
+	HeartbeatInterval time.Duration
+	// 心跳超时，默认 HeartbeatTimeout，超时未收到调度器的消息视为调度器已退出
+	HeartbeatTimeout time.Duration
+}
+
+/*
+连接调度器并执行下发的任务，直到 ctx 结束或连接断开：
+注册后本地创建 Capacity 大小的协程池执行任务，结果按完成顺序返回，
+并定期发送心跳，超过心跳超时时间未收到调度器的消息时返回错误；ctx 结束时返回 nil
+*/
+func RunRemoteWorker(ctx context.Context, addr string, options RemoteOptions) error {
+	if options.Capacity <= 0 {
+		options.Capacity = 1
+	}
+	if options.Codec == "" {
+		options.Codec = CodecJSON
+	}
+	if options.HeartbeatInterval <= 0 {
+		options.HeartbeatInterval = HeartbeatInterval
+	}
+	if options.HeartbeatTimeout <= 0 {
+		options.HeartbeatTimeout = HeartbeatTimeout
+	}
+	if options.Id == "" {
+		host, _ := os.Hostname()
+		options.Id = fmt.Sprintf("%s:%d", host, os.Getpid())
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	enc, err := newEncoder(options.Codec, conn)
+	if err != nil {
+		return err
+	}
+	dec, err := newDecoder(options.Codec, conn)
+	if err != nil {
+		return err
+	}
+	if err = writeHandshake(conn, options.Codec); err != nil {
+		return err
+	}
+
+	// 心跳与结果在不同协程中发送，写入需加锁
+	var writeMu sync.Mutex
+	write := func(msg *message) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return enc.Encode(msg)
+	}
+	if err = write(&message{Type: msgRegister, WorkerId: options.Id, Capacity: options.Capacity}); err != nil {
+		return err
+	}
+
+	pool, err := NewWithOptions(Options{Size: options.Capacity, QueueSize: options.Capacity})
+	if err != nil {
+		return err
+	}
+	defer pool.Stop()
+
+	// ctx 结束或心跳失败时关闭连接，使读取返回
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(options.HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if write(&message{Type: msgHeartbeat}) != nil {
+					conn.Close()
+					return
+				}
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(options.HeartbeatTimeout))
+		var msg message
+		if err = dec.Decode(&msg); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if msg.Type != msgJob {
+			continue
+		}
+		if err = runRemoteJob(ctx, pool, options.Handlers, &msg, write); err != nil {
+			return err
+		}
+	}
+}
+
+// 在本地协程池中执行任务，完成后返回结果
+func runRemoteJob(ctx context.Context, pool *Pool, handlers map[string]Handler, msg *message, write func(msg *message) error) error {
+	result := &message{Type: msgResult, JobId: msg.JobId}
+	task := func(ctx context.Context) error {
+		if msg.Name == "" {
+			result.Sum = DigitSum(msg.Number)
+			return nil
+		}
+		handler, ok := handlers[msg.Name]
+		if !ok {
+			return fmt.Errorf("No handler for job[%s]", msg.Name)
+		}
+		return handler(ctx, msg.Payload)
+	}
+	job, err := pool.Submit(ctx, task)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := job.Wait(); err != nil {
+			if ctx.Err() != nil {
+				// 远程协程退出时不返回结果，由调度器重新分配
+				return
+			}
+			result.Err = err.Error()
+		}
+		// 写入失败说明连接已断开，调度器会重新分配该任务
+		_ = write(result)
+	}()
+	return nil
+}
@@ -0,0 +1,71 @@
+package workpool
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// 重试默认参数
+const (
+	DefaultInitialBackoff = 100 * time.Millisecond
+	DefaultMaxBackoff     = 30 * time.Second
+	DefaultMultiplier     = 2
+)
+
+/*
+重试策略：
+第 n 次重试前等待 InitialBackoff * Multiplier^(n-1)，不超过 MaxBackoff，
+再按 Jitter 比例随机浮动，避免大量任务同时重试
+*/
+type RetryPolicy struct {
+	// 最大尝试次数（包含首次执行），小于等于 1 时不重试
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// 随机浮动比例，取值 0~1
+	Jitter float64
+	// 判断错误是否可以重试，为空时所有错误都重试
+	Retryable func(err error) bool
+}
+
+// 第 attempt 次执行失败后的等待时间
+func (r *RetryPolicy) Backoff(attempt int) time.Duration {
+	initial := r.InitialBackoff
+	if initial <= 0 {
+		initial = DefaultInitialBackoff
+	}
+	maxBackoff := r.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+	multiplier := r.Multiplier
+	if multiplier < 1 {
+		multiplier = DefaultMultiplier
+	}
+
+	backoff := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if backoff > float64(maxBackoff) {
+		backoff = float64(maxBackoff)
+	}
+	if r.Jitter > 0 {
+		jitter := math.Min(r.Jitter, 1)
+		backoff = backoff * (1 - jitter + 2*jitter*rand.Float64())
+	}
+	return time.Duration(backoff)
+}
+
+// 执行 attempt 次失败后是否继续重试
+func (r *RetryPolicy) shouldRetry(attempt int, err error) bool {
+	if r == nil || attempt >= r.MaxAttempts {
+		return false
+	}
+	return r.Retryable == nil || r.Retryable(err)
+}
+
+// 死信：重试耗尽或不可重试的失败任务，附带每次执行的错误
+type DeadLetter struct {
+	Job    *Job
+	Errors []error
+}
@@ -0,0 +1,221 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	policy := &RetryPolicy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond, Multiplier: 2}
+	expected := []time.Duration{10, 20, 40, 50, 50}
+	for i, backoff := range expected {
+		if policy.Backoff(i+1) != backoff*time.Millisecond {
+			t.Fatalf("Wrong backoff of attempt %d: %v", i+1, policy.Backoff(i+1))
+		}
+	}
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		backoff := policy.Backoff(1)
+		if backoff < 5*time.Millisecond || backoff > 15*time.Millisecond {
+			t.Fatalf("Backoff out of jitter range: %v", backoff)
+		}
+	}
+}
+
+func TestPoolRetryAndDeadLetter(t *testing.T) {
+	letters := make(chan *DeadLetter, 2)
+	pool, err := NewWithOptions(Options{
+		Size:  2,
+		Retry: &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+		OnDeadLetter: func(letter *DeadLetter) {
+			letters <- letter
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Stop()
+
+	// 第三次成功
+	var calls int
+	job, _ := pool.Submit(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("temporary")
+		}
+		return nil
+	})
+	if err = job.Wait(); err != nil || job.Attempts() != 3 || len(job.Errors()) != 2 {
+		t.Fatalf("Retry failed, err:%v, attempts:%d", err, job.Attempts())
+	}
+
+	// 重试耗尽进入死信
+	failed := errors.New("always")
+	job, _ = pool.Submit(context.Background(), func(ctx context.Context) error {
+		return failed
+	})
+	if err = job.Wait(); err != failed {
+		t.Fatalf("Wrong error:%v", err)
+	}
+	letter := <-letters
+	if letter.Job != job || len(letter.Errors) != 3 {
+		t.Fatalf("Wrong dead letter:%#v", letter)
+	}
+
+	// 不可重试的错误
+	fatal := errors.New("fatal")
+	job = &Job{
+		Task: func(ctx context.Context) error {
+			return fatal
+		},
+		Retry: &RetryPolicy{MaxAttempts: 5, Retryable: func(err error) bool {
+			return err != fatal
+		}},
+	}
+	_ = pool.SubmitJob(context.Background(), job)
+	if err = job.Wait(); err != fatal || job.Attempts() != 1 {
+		t.Fatalf("Fatal error should not retry, attempts:%d", job.Attempts())
+	}
+	<-letters
+}
+
+func TestPoolJobTimeout(t *testing.T) {
+	pool, err := NewWithOptions(Options{Size: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Stop()
+	exited := make(chan struct{})
+	job := &Job{
+		Timeout: 20 * time.Millisecond,
+		Task: func(ctx context.Context) error {
+			defer close(exited)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+				return nil
+			}
+		},
+	}
+	_ = pool.SubmitJob(context.Background(), job)
+	if err = job.Wait(); err != context.DeadlineExceeded {
+		t.Fatalf("Job should time out, err:%v", err)
+	}
+	// 超时的任务随 ctx 退出，不会遗留协程
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out task still running")
+	}
+
+	// 超时后协程可以继续处理其他任务
+	next, _ := pool.Submit(context.Background(), func(ctx context.Context) error {
+		return nil
+	})
+	if err = next.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPoolHungTask(t *testing.T) {
+	pool, err := NewWithOptions(Options{Size: 1, Timeout: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Stop()
+	release := make(chan struct{})
+	defer close(release)
+	// 不响应 ctx 的任务
+	hung, _ := pool.Submit(context.Background(), func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	var jobs []*Job
+	for i := 0; i < 5; i++ {
+		job, err := pool.Submit(context.Background(), func(ctx context.Context) error {
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err = hung.Wait(); err != context.DeadlineExceeded {
+		t.Fatalf("Hung task should time out, err:%v", err)
+	}
+	// 唯一的协程在超时后继续处理其他任务
+	for _, job := range jobs {
+		select {
+		case <-job.Done():
+		case <-time.After(time.Second):
+			t.Fatal("Pool blocked by hung task")
+		}
+		if err = job.Wait(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPoolRetryReleasesWorker(t *testing.T) {
+	pool, err := NewWithOptions(Options{
+		Size:  1,
+		Retry: &RetryPolicy{MaxAttempts: 2, InitialBackoff: 200 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := errors.New("temporary")
+	var calls int32
+	retried, _ := pool.Submit(context.Background(), func(ctx context.Context) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return failed
+		}
+		return nil
+	})
+	for atomic.LoadInt32(&calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// 退避期间唯一的协程可以执行其他任务
+	start := time.Now()
+	next, _ := pool.Submit(context.Background(), func(ctx context.Context) error {
+		return nil
+	})
+	if err = next.Wait(); err != nil || time.Since(start) > 100*time.Millisecond {
+		t.Fatalf("Retry backoff blocked the worker, err:%v, elapsed:%v", err, time.Since(start))
+	}
+	select {
+	case <-retried.Done():
+		t.Fatal("Retry should wait for backoff")
+	default:
+	}
+
+	// StopWait 等待重试完成
+	pool.StopWait()
+	if err = retried.Wait(); err != nil || retried.Attempts() != 2 {
+		t.Fatalf("Retry failed, err:%v, attempts:%d", err, retried.Attempts())
+	}
+
+	// Stop 取消等待中的重试，以最后一次的错误结束
+	pool, _ = NewWithOptions(Options{
+		Size:  1,
+		Retry: &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Hour},
+	})
+	calls = 0
+	job, _ := pool.Submit(context.Background(), func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return failed
+	})
+	for atomic.LoadInt32(&calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	pool.Stop()
+	if err = job.Wait(); err != failed {
+		t.Fatalf("Wrong error:%v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package workpool
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// 单个租户的任务堆
+type jobHeap struct {
+	jobs  []*Job
+	aging time.Duration
+}
+
+func (h *jobHeap) Len() int {
+	return len(h.jobs)
+}
+
+/*
+优先级高的在前，同优先级先提交的在前；
+开启老化时等待时间每增加 aging 相当于提升一级，
+两个任务的先后关系不随时间变化，可以直接比较 Priority*aging - 提交时间
+*/
+func (h *jobHeap) Less(i, j int) bool {
+	a, b := h.jobs[i], h.jobs[j]
+	if h.aging > 0 {
+		scoreA := int64(a.Priority)*int64(h.aging) - a.submitted.UnixNano()
+		scoreB := int64(b.Priority)*int64(h.aging) - b.submitted.UnixNano()
+		if scoreA != scoreB {
+			return scoreA > scoreB
+		}
+	} else if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	return a.seq < b.seq
+}
+
+func (h *jobHeap) Swap(i, j int) {
+	h.jobs[i], h.jobs[j] = h.jobs[j], h.jobs[i]
+}
+
+func (h *jobHeap) Push(x interface{}) {
+	h.jobs = append(h.jobs, x.(*Job))
+}
+
+func (h *jobHeap) Pop() interface{} {
+	n := len(h.jobs)
+	job := h.jobs[n-1]
+	h.jobs[n-1] = nil
+	h.jobs = h.jobs[:n-1]
+	return job
+}
+
+/*
+优先级队列：
+每个租户一个任务堆，出队时先比较各租户队首任务的当前优先级（含老化提升），
+优先级相同的租户之间轮询，避免单个租户的大量任务饿死其他租户
+*/
+type priorityQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	aging   time.Duration
+	fair    bool
+	tenants map[string]*jobHeap
+	order   []string
+	cursor  int
+	size    int
+	seq     uint64
+	closed  bool
+	drained chan struct{}
+}
+
+func newPriorityQueue(aging time.Duration, fair bool) *priorityQueue {
+	q := &priorityQueue{
+		aging:   aging,
+		fair:    fair,
+		tenants: make(map[string]*jobHeap),
+		drained: make(chan struct{}),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// 入队
+func (q *priorityQueue) push(job *Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	tenant := ""
+	if q.fair {
+		tenant = job.Tenant
+	}
+	h, ok := q.tenants[tenant]
+	if !ok {
+		h = &jobHeap{aging: q.aging}
+		q.tenants[tenant] = h
+		q.order = append(q.order, tenant)
+	}
+	q.seq++
+	job.seq = q.seq
+	heap.Push(h, job)
+	q.size++
+	q.cond.Signal()
+}
+
+// 队列长度
+func (q *priorityQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
+
+// 关闭队列，剩余任务仍可出队
+func (q *priorityQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	if q.size == 0 {
+		close(q.drained)
+	}
+	q.cond.Broadcast()
+}
+
+// 队列关闭且任务全部出队时关闭的管道
+func (q *priorityQueue) Drained() <-chan struct{} {
+	return q.drained
+}
+
+// 阻塞等待出队，队列关闭且为空时返回 false
+func (q *priorityQueue) pop() (*Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.size == 0 {
+		if q.closed {
+			return nil, false
+		}
+		q.cond.Wait()
+	}
+
+	// 从上次出队的下一个租户开始，选择当前优先级最高的租户
+	now := time.Now()
+	chosen := -1
+	var best int
+	for i := 0; i < len(q.order); i++ {
+		index := (q.cursor + i) % len(q.order)
+		h := q.tenants[q.order[index]]
+		level := q.level(h.jobs[0], now)
+		if chosen == -1 || level > best {
+			chosen, best = index, level
+		}
+	}
+
+	tenant := q.order[chosen]
+	h := q.tenants[tenant]
+	job := heap.Pop(h).(*Job)
+	q.size--
+	if q.size == 0 && q.closed {
+		close(q.drained)
+	}
+	if h.Len() == 0 {
+		delete(q.tenants, tenant)
+		q.order = append(q.order[:chosen], q.order[chosen+1:]...)
+		q.cursor = chosen
+	} else {
+		q.cursor = chosen + 1
+	}
+	if len(q.order) > 0 {
+		q.cursor %= len(q.order)
+	} else {
+		q.cursor = 0
+	}
+	return job, true
+}
+
+// 任务当前优先级，开启老化时按等待时间提升
+func (q *priorityQueue) level(job *Job, now time.Time) int {
+	if q.aging <= 0 {
+		return job.Priority
+	}
+	return job.Priority + int(now.Sub(job.submitted)/q.aging)
+}
@@ -0,0 +1,106 @@
+package workpool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 单协程执行，先提交一个阻塞任务，再按 jobs 顺序提交，返回实际执行顺序
+func runOrder(t *testing.T, options Options, jobs []*Job, beforeRelease func()) []string {
+	options.Size = 1
+	pool, err := NewWithOptions(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Stop()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	_, _ = pool.Submit(context.Background(), func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	for i, job := range jobs {
+		name := fmt.Sprintf("%s%d", job.Tenant, i)
+		job.Task = func(ctx context.Context) error {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return nil
+		}
+		if err = pool.SubmitJob(context.Background(), job); err != nil {
+			t.Fatal(err)
+		}
+		if beforeRelease != nil && i == 0 {
+			beforeRelease()
+		}
+	}
+	close(release)
+	for _, job := range jobs {
+		_ = job.Wait()
+	}
+	return order
+}
+
+func TestPoolPriority(t *testing.T) {
+	order := runOrder(t, Options{Priority: true}, []*Job{
+		{Priority: 0}, {Priority: 5}, {Priority: 1}, {Priority: 5},
+	}, nil)
+	if strings.Join(order, ",") != "1,3,2,0" {
+		t.Fatalf("Wrong priority order:%v", order)
+	}
+}
+
+func TestPoolFairShare(t *testing.T) {
+	order := runOrder(t, Options{FairShare: true}, []*Job{
+		{Tenant: "a"}, {Tenant: "a"}, {Tenant: "a"}, {Tenant: "a"}, {Tenant: "b"}, {Tenant: "b"},
+	}, nil)
+	if strings.Join(order, ",") != "a0,b4,a1,b5,a2,a3" {
+		t.Fatalf("Wrong fair share order:%v", order)
+	}
+}
+
+func TestPoolAging(t *testing.T) {
+	order := runOrder(t, Options{Priority: true, AgingInterval: 10 * time.Millisecond}, []*Job{
+		{Priority: 0}, {Priority: 2},
+	}, func() {
+		time.Sleep(35 * time.Millisecond)
+	})
+	if strings.Join(order, ",") != "0,1" {
+		t.Fatalf("Low priority job should be aged up:%v", order)
+	}
+}
+
+func TestPoolPriorityStopWait(t *testing.T) {
+	pool, err := NewWithOptions(Options{Size: 2, Priority: true, Results: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		_ = pool.SubmitJob(context.Background(), &Job{Id: i, Number: i, Priority: i % 3})
+	}
+	count := 0
+	done := make(chan struct{})
+	go func() {
+		for range pool.Results() {
+			count++
+		}
+		close(done)
+	}()
+	pool.StopWait()
+	<-done
+	if count != 50 {
+		t.Fatalf("StopWait should drain priority queue, count:%d", count)
+	}
+}
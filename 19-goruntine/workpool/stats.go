@@ -0,0 +1,157 @@
+package workpool
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 耗时直方图默认分桶上限（秒）
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30}
+
+// 耗时直方图快照，Counts[i] 为耗时不超过 Buckets[i] 的累计次数
+type Histogram struct {
+	Buckets []float64 `json:"buckets"`
+	Counts  []uint64  `json:"counts"`
+	Count   uint64    `json:"count"`
+	// 总耗时（秒）
+	Sum float64 `json:"sum"`
+}
+
+// 按耗时分桶计数
+type histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+}
+
+// 记录一次耗时
+func (h *histogram) observe(d time.Duration) {
+	seconds := d.Seconds()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range h.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// 生成累计计数的快照
+func (h *histogram) snapshot() Histogram {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	snapshot := Histogram{
+		Buckets: append([]float64(nil), h.buckets...),
+		Counts:  make([]uint64, len(h.counts)),
+		Count:   h.count,
+		Sum:     h.sum,
+	}
+	var total uint64
+	for i, count := range h.counts {
+		total += count
+		snapshot.Counts[i] = total
+	}
+	return snapshot
+}
+
+// 协程池运行统计
+type Stats struct {
+	// 已提交的任务数
+	Submitted uint64 `json:"submitted"`
+	// 正在执行的任务数
+	Running int64 `json:"running"`
+	// 执行成功的任务数
+	Completed uint64 `json:"completed"`
+	// 最终失败的任务数，包含停止时未执行的任务
+	Failed uint64 `json:"failed"`
+	// 排队中的任务数
+	Queued int `json:"queued"`
+	// 当前协程数量
+	Workers int `json:"workers"`
+	// 任务 panic 的次数
+	Panics uint64 `json:"panics"`
+	// 排队等待耗时
+	Wait Histogram `json:"wait"`
+	// 执行耗时，每次执行单独计算，不包含限流等待
+	Exec Histogram `json:"exec"`
+}
+
+// 当前运行统计
+func (p *Pool) Stats() Stats {
+	return Stats{
+		Submitted: atomic.LoadUint64(&p.submitted),
+		Running:   atomic.LoadInt64(&p.running),
+		Completed: atomic.LoadUint64(&p.completed),
+		Failed:    atomic.LoadUint64(&p.failed),
+		Queued:    p.backlog(),
+		Workers:   p.Workers(),
+		Panics:    p.Panics(),
+		Wait:      p.waitLatency.snapshot(),
+		Exec:      p.execLatency.snapshot(),
+	}
+}
+
+/*
+运行统计接口：
+默认输出 JSON，请求参数 format=prometheus 或 Accept 为 text/plain 时
+输出 Prometheus 文本格式
+*/
+func (p *Pool) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stats := p.Stats()
+		if r.URL.Query().Get("format") == "prometheus" || strings.Contains(r.Header.Get("Accept"), "text/plain") {
+			w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+			stats.WritePrometheus(w, "workpool")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(stats)
+	})
+}
+
+// 按 Prometheus 文本格式输出，指标名以 namespace 为前缀
+func (s Stats) WritePrometheus(w io.Writer, namespace string) {
+	metric := func(name string, kind string, help string, value string) {
+		name = namespace + "_" + name
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", name, help, name, kind, name, value)
+	}
+	metric("jobs_submitted_total", "counter", "Total number of submitted jobs.", strconv.FormatUint(s.Submitted, 10))
+	metric("jobs_completed_total", "counter", "Total number of successful jobs.", strconv.FormatUint(s.Completed, 10))
+	metric("jobs_failed_total", "counter", "Total number of failed jobs.", strconv.FormatUint(s.Failed, 10))
+	metric("jobs_panics_total", "counter", "Total number of job panics.", strconv.FormatUint(s.Panics, 10))
+	metric("jobs_running", "gauge", "Number of running jobs.", strconv.FormatInt(s.Running, 10))
+	metric("queue_length", "gauge", "Number of queued jobs.", strconv.Itoa(s.Queued))
+	metric("workers", "gauge", "Number of workers.", strconv.Itoa(s.Workers))
+	writeHistogram(w, namespace+"_job_wait_seconds", "Time jobs spent waiting in the queue.", s.Wait)
+	writeHistogram(w, namespace+"_job_exec_seconds", "Time jobs spent executing per attempt, excluding rate limit waits.", s.Exec)
+}
+
+// 输出 Prometheus 直方图
+func writeHistogram(w io.Writer, name string, help string, h Histogram) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for i, bound := range h.Buckets {
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", name, strconv.FormatFloat(bound, 'g', -1, 64), h.Counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, h.Count)
+	fmt.Fprintf(w, "%s_sum %s\n", name, strconv.FormatFloat(h.Sum, 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count %d\n", name, h.Count)
+}
@@ -0,0 +1,111 @@
+package workpool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPoolStats(t *testing.T) {
+	pool, err := NewWithOptions(Options{Size: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Stop()
+
+	release := make(chan struct{})
+	blocked, err := pool.Submit(context.Background(), func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed, err := pool.Submit(context.Background(), func(ctx context.Context) error {
+		return errors.New("failed")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for pool.Stats().Running != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	stats := pool.Stats()
+	if stats.Submitted != 2 || stats.Running != 1 || stats.Queued != 1 || stats.Workers != 1 {
+		t.Fatalf("Wrong stats:%+v", stats)
+	}
+
+	close(release)
+	_ = blocked.Wait()
+	_ = failed.Wait()
+	stats = pool.Stats()
+	if stats.Completed != 1 || stats.Failed != 1 || stats.Running != 0 || stats.Queued != 0 {
+		t.Fatalf("Wrong stats:%+v", stats)
+	}
+	if stats.Wait.Count != 2 || stats.Exec.Count != 2 {
+		t.Fatalf("Wrong histogram count, wait:%d, exec:%d", stats.Wait.Count, stats.Exec.Count)
+	}
+	last := len(stats.Exec.Counts) - 1
+	if stats.Exec.Counts[last] != 2 || stats.Exec.Sum <= 0 {
+		t.Fatalf("Wrong exec histogram:%+v", stats.Exec)
+	}
+}
+
+func TestPoolStatsHandler(t *testing.T) {
+	pool, err := NewWithOptions(Options{Size: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Stop()
+	job, err := pool.Submit(context.Background(), func(ctx context.Context) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = job.Wait()
+
+	server := httptest.NewServer(pool.Handler())
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats Stats
+	err = json.NewDecoder(resp.Body).Decode(&stats)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Submitted != 1 || stats.Completed != 1 {
+		t.Fatalf("Wrong stats:%+v", stats)
+	}
+
+	resp, err = server.Client().Get(server.URL + "?format=prometheus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"workpool_jobs_submitted_total 1",
+		"workpool_jobs_completed_total 1",
+		"# TYPE workpool_job_wait_seconds histogram",
+		`workpool_job_exec_seconds_bucket{le="+Inf"} 1`,
+		"workpool_job_exec_seconds_count 1",
+	} {
+		if !strings.Contains(string(body), line) {
+			t.Fatalf("Missing line:%s, body:\n%s", line, body)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+)
+
+// 在工作窃取任务之外调用 Spawn
+var ErrNotInWorker = errors.New("Spawn must be called inside a work-stealing task")
+
+// 工作窃取协程池参数
+type StealingOptions struct {
+	// 协程数量
+	Size int
+	// 任务失败时的回调，在执行任务的协程中调用
+	OnError func(err error)
+}
+
+/*
+工作窃取协程池：
+每个协程有自己的双端队列，自己从队尾取任务（后进先出，利于缓存），
+空闲时随机选择其他协程从队首窃取（先进先出，优先窃取较大的任务）；
+外部提交按轮询分配到各协程队列，任务内通过 Spawn 提交的子任务放入当前协程队列，
+避免所有协程争用同一个 jobChan
+*/
+type StealingPool struct {
+	// 原子计数放在首位，保证 32 位平台上 8 字节对齐
+	pending uint64
+	pushes  uint64
+	idle    int64
+	next    uint64
+	options StealingOptions
+	workers []*stealWorker
+	ctx     context.Context
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+	cond    *sync.Cond
+	closed  bool
+	// Submit 持有读锁检查 closed 并入队，关闭时持有写锁，避免任务放入已退出协程的队列
+	submitMu sync.RWMutex
+	tasks    sync.WaitGroup
+	wg       sync.WaitGroup
+}
+
+// 工作窃取协程
+type stealWorker struct {
+	pool  *StealingPool
+	deque *deque
+	ctx   context.Context
+	rand  *rand.Rand
+}
+
+// 执行上下文中保存当前协程的 key
+type stealWorkerKey struct{}
+
+// 创建工作窃取协程池
+func NewStealingPool(options StealingOptions) (*StealingPool, error) {
+	if options.Size <= 0 {
+		return nil, ErrInvalidSize
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &StealingPool{
+		options: options,
+		workers: make([]*stealWorker, options.Size),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	p.cond = sync.NewCond(&p.mu)
+	for i := range p.workers {
+		w := &stealWorker{
+			pool:  p,
+			deque: newDeque(),
+			rand:  rand.New(rand.NewSource(int64(i) + 1)),
+		}
+		w.ctx = context.WithValue(ctx, stealWorkerKey{}, w)
+		p.workers[i] = w
+	}
+	for _, w := range p.workers {
+		p.wg.Add(1)
+		go w.run()
+	}
+	return p, nil
+}
+
+// 提交任务，按轮询放入某个协程的队列
+func (p *StealingPool) Submit(task Task) error {
+	if task == nil {
+		return errors.New("Task is nil")
+	}
+	p.submitMu.RLock()
+	defer p.submitMu.RUnlock()
+	if p.closed {
+		return ErrStopped
+	}
+	index := atomic.AddUint64(&p.next, 1) % uint64(len(p.workers))
+	p.push(p.workers[index], task)
+	return nil
+}
+
+// 在任务内提交子任务，放入当前协程的队列
+func Spawn(ctx context.Context, task Task) error {
+	if task == nil {
+		return errors.New("Task is nil")
+	}
+	w, ok := ctx.Value(stealWorkerKey{}).(*stealWorker)
+	if !ok {
+		return ErrNotInWorker
+	}
+	w.pool.push(w, task)
+	return nil
+}
+
+/*
+任务入队并唤醒空闲协程：
+先增加 pending 再入队，保证取出任务后减少 pending 时不会小于 0；
+入队后增加 pushes 再检查 idle，协程休眠前先增加 idle 再检查 pushes，
+两者至少有一方能看到对方的修改，不会丢失唤醒
+*/
+func (p *StealingPool) push(w *stealWorker, task Task) {
+	p.tasks.Add(1)
+	atomic.AddUint64(&p.pending, 1)
+	w.deque.pushBottom(task)
+	atomic.AddUint64(&p.pushes, 1)
+	if atomic.LoadInt64(&p.idle) > 0 {
+		p.mu.Lock()
+		p.cond.Signal()
+		p.mu.Unlock()
+	}
+}
+
+// 排队中的任务数量
+func (p *StealingPool) Queued() int {
+	return int(atomic.LoadUint64(&p.pending))
+}
+
+// 等待已提交的任务及其子任务全部完成，不能与 Submit 并发调用
+func (p *StealingPool) Wait() {
+	p.tasks.Wait()
+}
+
+// 等待任务全部完成后停止
+func (p *StealingPool) StopWait() {
+	p.Wait()
+	p.shutdown()
+}
+
+// 立即停止：取消正在执行的任务，丢弃队列中的任务
+func (p *StealingPool) Stop() {
+	p.cancel()
+	p.shutdown()
+}
+
+// 通知协程退出并等待
+func (p *StealingPool) shutdown() {
+	p.submitMu.Lock()
+	p.mu.Lock()
+	p.closed = true
+	p.cond.Broadcast()
+	p.mu.Unlock()
+	p.submitMu.Unlock()
+	p.wg.Wait()
+	p.cancel()
+}
+
+// 协程处理函数
+func (w *stealWorker) run() {
+	defer w.pool.wg.Done()
+	for {
+		seen := atomic.LoadUint64(&w.pool.pushes)
+		task, ok := w.deque.popBottom()
+		if !ok {
+			task, ok = w.steal()
+		}
+		if ok {
+			atomic.AddUint64(&w.pool.pending, ^uint64(0))
+			w.execute(task)
+			continue
+		}
+		if !w.pool.park(seen) {
+			return
+		}
+	}
+}
+
+// 从其他协程的队首窃取任务，从随机位置开始依次尝试
+func (w *stealWorker) steal() (Task, bool) {
+	workers := w.pool.workers
+	start := w.rand.Intn(len(workers))
+	for i := 0; i < len(workers); i++ {
+		victim := workers[(start+i)%len(workers)]
+		if victim == w {
+			continue
+		}
+		if task, ok := victim.deque.popTop(); ok {
+			return task, true
+		}
+	}
+	return nil, false
+}
+
+// 执行任务，协程池停止后直接丢弃
+func (w *stealWorker) execute(task Task) {
+	defer w.pool.tasks.Done()
+	if w.ctx.Err() != nil {
+		return
+	}
+	err := protect(func() error {
+		return task(w.ctx)
+	})
+	if err != nil && w.pool.options.OnError != nil {
+		w.pool.options.OnError(err)
+	}
+}
+
+/*
+没有任务时休眠：
+seen 为查找任务前的入队次数，之后有新任务入队才返回 true，
+所有队列为空但其他协程刚取出任务、尚未减少 pending 时不会空转；
+协程池关闭且没有新任务时返回 false
+*/
+func (p *StealingPool) park(seen uint64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	atomic.AddInt64(&p.idle, 1)
+	defer atomic.AddInt64(&p.idle, -1)
+	for atomic.LoadUint64(&p.pushes) == seen {
+		if p.closed {
+			return false
+		}
+		p.cond.Wait()
+	}
+	return true
+}
+
+// 加锁的环形双端队列，容量不足时翻倍
+type deque struct {
+	mu    sync.Mutex
+	tasks []Task
+	head  int
+	size  int
+}
+
+func newDeque() *deque {
+	return &deque{tasks: make([]Task, 64)}
+}
+
+// 队尾入队
+func (d *deque) pushBottom(task Task) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.size == len(d.tasks) {
+		tasks := make([]Task, 2*len(d.tasks))
+		for i := 0; i < d.size; i++ {
+			tasks[i] = d.tasks[(d.head+i)%len(d.tasks)]
+		}
+		d.tasks, d.head = tasks, 0
+	}
+	d.tasks[(d.head+d.size)%len(d.tasks)] = task
+	d.size++
+}
+
+// 队尾出队，由所属协程调用
+func (d *deque) popBottom() (Task, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.size == 0 {
+		return nil, false
+	}
+	d.size--
+	index := (d.head + d.size) % len(d.tasks)
+	task := d.tasks[index]
+	d.tasks[index] = nil
+	return task, true
+}
+
+// 队首出队，由窃取的协程调用
+func (d *deque) popTop() (Task, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.size == 0 {
+		return nil, false
+	}
+	task := d.tasks[d.head]
+	d.tasks[d.head] = nil
+	d.head = (d.head + 1) % len(d.tasks)
+	d.size--
+	return task, true
+}
@@ -0,0 +1,143 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStealingPool(t *testing.T) {
+	var failed int32
+	pool, err := NewStealingPool(StealingOptions{
+		Size: 4,
+		OnError: func(err error) {
+			atomic.AddInt32(&failed, 1)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count, maxQueued int32
+	for i := 0; i < 1000; i++ {
+		i := i
+		err = pool.Submit(func(ctx context.Context) error {
+			atomic.AddInt32(&count, 1)
+			// 取出任务后 pending 不会先于入队减少而回绕
+			if queued := int32(pool.Queued()); queued > atomic.LoadInt32(&maxQueued) {
+				atomic.StoreInt32(&maxQueued, queued)
+			}
+			if i%100 == 0 {
+				return errors.New("failed")
+			}
+			if i == 555 {
+				panic("boom")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	pool.StopWait()
+	if count != 1000 || failed != 11 {
+		t.Fatalf("Wrong result, count:%d, failed:%d", count, failed)
+	}
+	if maxQueued > 1000 || pool.Queued() != 0 {
+		t.Fatalf("Wrong queued, max:%d, now:%d", maxQueued, pool.Queued())
+	}
+	if err = pool.Submit(func(ctx context.Context) error { return nil }); err != ErrStopped {
+		t.Fatalf("Submit after stop should fail, err:%v", err)
+	}
+}
+
+func TestStealingSubmitRaceStop(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		pool, err := NewStealingPool(StealingOptions{Size: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var wg sync.WaitGroup
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for pool.Submit(func(ctx context.Context) error { return nil }) == nil {
+					runtime.Gosched()
+				}
+			}()
+		}
+		pool.Stop()
+		wg.Wait()
+		// 与 Stop 并发提交成功的任务也会被执行或丢弃，不会一直等待
+		done := make(chan struct{})
+		go func() {
+			pool.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(3 * time.Second):
+			t.Fatal("Task submitted during stop was lost")
+		}
+	}
+}
+
+// 递归拆分为子任务求和 [low, high)
+func spawnSum(low, high int, sum *int64) Task {
+	return func(ctx context.Context) error {
+		if high-low <= 8 {
+			for i := low; i < high; i++ {
+				atomic.AddInt64(sum, int64(i))
+			}
+			return nil
+		}
+		mid := (low + high) / 2
+		if err := Spawn(ctx, spawnSum(low, mid, sum)); err != nil {
+			return err
+		}
+		return Spawn(ctx, spawnSum(mid, high, sum))
+	}
+}
+
+func TestStealingSpawn(t *testing.T) {
+	pool, err := NewStealingPool(StealingOptions{Size: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Stop()
+	var sum int64
+	if err = pool.Submit(spawnSum(0, 10000, &sum)); err != nil {
+		t.Fatal(err)
+	}
+	pool.Wait()
+	if sum != 49995000 {
+		t.Fatalf("Wrong sum, expect:49995000, got:%d", sum)
+	}
+
+	if err = Spawn(context.Background(), func(ctx context.Context) error { return nil }); err != ErrNotInWorker {
+		t.Fatalf("Spawn outside worker should fail, err:%v", err)
+	}
+}
+
+func TestDeque(t *testing.T) {
+	d := newDeque()
+	var order []int
+	for i := 0; i < 100; i++ {
+		i := i
+		d.pushBottom(func(ctx context.Context) error {
+			order = append(order, i)
+			return nil
+		})
+	}
+	top, _ := d.popTop()
+	bottom, _ := d.popBottom()
+	_ = top(nil)
+	_ = bottom(nil)
+	if order[0] != 0 || order[1] != 99 || d.size != 98 {
+		t.Fatalf("Wrong deque order:%v, size:%d", order, d.size)
+	}
+}
@@ -0,0 +1,124 @@
+package workpool
+
+import (
+	"context"
+	"sort"
+)
+
+// 流式处理的单个输出，Index 为输入序号
+type Output struct {
+	Index int
+	Value interface{}
+	Err   error
+}
+
+// 归并函数，返回新的累计值
+type ReduceFunc func(acc interface{}, value interface{}) (interface{}, error)
+
+/*
+流式映射：
+以 concurrency 个协程处理 inputs，按完成顺序输出，inputs 关闭且全部处理完后关闭输出管道；
+单个输入出错不影响其他输入，ctx 结束时停止读取并丢弃未输出的结果
+*/
+func MapStream(ctx context.Context, inputs <-chan interface{}, fn MapFunc, concurrency int) (<-chan Output, error) {
+	if concurrency <= 0 {
+		return nil, ErrInvalidSize
+	}
+	pool, err := NewWithOptions(Options{Size: concurrency, QueueSize: concurrency})
+	if err != nil {
+		return nil, err
+	}
+	outputs := make(chan Output, concurrency)
+	go func() {
+		defer close(outputs)
+		defer pool.StopWait()
+		for index := 0; ; index++ {
+			var (
+				input interface{}
+				ok    bool
+			)
+			select {
+			case input, ok = <-inputs:
+			case <-ctx.Done():
+			}
+			if !ok {
+				return
+			}
+			index := index
+			_, err := pool.Submit(ctx, func(ctx context.Context) error {
+				value, err := fn(ctx, input)
+				select {
+				case outputs <- Output{Index: index, Value: value, Err: err}:
+				case <-ctx.Done():
+				}
+				return nil
+			})
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return outputs, nil
+}
+
+/*
+流式归并 outputs，遇到第一个错误时返回当前累计值与错误，
+提前返回时在后台继续消费 outputs，避免生产方阻塞
+*/
+func Reduce(outputs <-chan Output, initial interface{}, fn ReduceFunc) (interface{}, error) {
+	acc := initial
+	for output := range outputs {
+		err := output.Err
+		if err == nil {
+			acc, err = fn(acc, output.Value)
+		}
+		if err != nil {
+			go drain(outputs)
+			return acc, err
+		}
+	}
+	return acc, nil
+}
+
+/*
+流式归并协程池的 Results 管道，直到管道关闭，遇到第一个错误时返回，
+提前返回时与 Reduce 一样在后台继续消费 results
+*/
+func ReduceResults(results <-chan *Result, initial interface{}, fn func(acc interface{}, result *Result) (interface{}, error)) (interface{}, error) {
+	acc := initial
+	for result := range results {
+		err := result.Err
+		if err == nil {
+			acc, err = fn(acc, result)
+		}
+		if err != nil {
+			go drainResults(results)
+			return acc, err
+		}
+	}
+	return acc, nil
+}
+
+// 收集协程池的 Results 管道直到关闭，按 Job.Id 排序
+func CollectResults(results <-chan *Result) []*Result {
+	var collected []*Result
+	for result := range results {
+		collected = append(collected, result)
+	}
+	sort.SliceStable(collected, func(i, j int) bool {
+		return collected[i].Job.Id < collected[j].Job.Id
+	})
+	return collected
+}
+
+// 消费剩余输出
+func drain(outputs <-chan Output) {
+	for range outputs {
+	}
+}
+
+// 消费剩余结果
+func drainResults(results <-chan *Result) {
+	for range results {
+	}
+}
@@ -0,0 +1,252 @@
+package workpool
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// 预写日志记录类型
+const (
+	walSubmit = "submit"
+	walDone   = "done"
+)
+
+// 预写日志记录，每行一条 JSON
+type walRecord struct {
+	Op       string `json:"op"`
+	Id       uint64 `json:"id"`
+	Name     string `json:"name,omitempty"`
+	Payload  []byte `json:"payload,omitempty"`
+	Priority int    `json:"priority,omitempty"`
+	Tenant   string `json:"tenant,omitempty"`
+	RateKey  string `json:"rate_key,omitempty"`
+}
+
+/*
+任务预写日志：
+任务提交时先追加 submit 记录并刷盘再确认，完成后追加 done 记录，
+重启时没有 done 记录的任务需要重新执行；压缩时只保留未完成的 submit 记录
+*/
+type wal struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	seq     uint64
+	pending map[uint64]*walRecord
+	dones   int
+	closed  bool
+}
+
+// 打开预写日志，返回按提交顺序排列的未完成任务
+func openWAL(path string) (*wal, []*walRecord, error) {
+	w := &wal{
+		path:    path,
+		pending: make(map[uint64]*walRecord),
+	}
+	if err := w.load(); err != nil {
+		return nil, nil, err
+	}
+	// 启动时先压缩，清理已完成的记录
+	if err := w.rewrite(); err != nil {
+		return nil, nil, err
+	}
+
+	records := make([]*walRecord, 0, len(w.pending))
+	for _, record := range w.pending {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Id < records[j].Id
+	})
+	return w, records, nil
+}
+
+// 读取日志文件
+func (w *wal) load() error {
+	file, err := os.Open(w.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to open wal[%s], err:%v", w.path, err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for lineNo := 1; ; lineNo++ {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// 最后一行没有换行符说明写入时进程退出，该记录未被确认，直接忽略
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to read wal[%s], err:%v", w.path, err)
+		}
+		var record walRecord
+		if err = json.Unmarshal(line, &record); err != nil {
+			return fmt.Errorf("Corrupted wal[%s], line:%d, err:%v", w.path, lineNo, err)
+		}
+		if record.Id > w.seq {
+			w.seq = record.Id
+		}
+		switch record.Op {
+		case walSubmit:
+			r := record
+			w.pending[record.Id] = &r
+		case walDone:
+			delete(w.pending, record.Id)
+		}
+	}
+}
+
+// 追加记录并刷盘
+func (w *wal) write(record *walRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if _, err = w.file.Write(data); err != nil {
+		return fmt.Errorf("Failed to write wal[%s], err:%v", w.path, err)
+	}
+	return w.file.Sync()
+}
+
+// 记录提交的任务，返回任务编号
+func (w *wal) submit(job *Job) (uint64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, ErrStopped
+	}
+	w.seq++
+	record := &walRecord{
+		Op:       walSubmit,
+		Id:       w.seq,
+		Name:     job.Name,
+		Payload:  job.Payload,
+		Priority: job.Priority,
+		Tenant:   job.Tenant,
+		RateKey:  job.RateKey,
+	}
+	if err := w.write(record); err != nil {
+		return 0, err
+	}
+	w.pending[record.Id] = record
+	return record.Id, nil
+}
+
+// 记录任务完成
+func (w *wal) done(id uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.pending[id]; !ok || w.closed {
+		return nil
+	}
+	if err := w.write(&walRecord{Op: walDone, Id: id}); err != nil {
+		return err
+	}
+	delete(w.pending, id)
+	w.dones++
+	return nil
+}
+
+// 压缩日志，自上次压缩后没有完成的任务时跳过
+func (w *wal) compact() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed || w.dones == 0 {
+		return nil
+	}
+	return w.rewrite()
+}
+
+// 未完成的记录写入临时文件后替换原文件，调用方持有锁
+func (w *wal) rewrite() error {
+	ids := make([]uint64, 0, len(w.pending))
+	for id := range w.pending {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	tmpPath := w.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to compact wal[%s], err:%v", w.path, err)
+	}
+	writer := bufio.NewWriter(tmp)
+	for _, id := range ids {
+		data, err := json.Marshal(w.pending[id])
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		_, _ = writer.Write(append(data, '\n'))
+	}
+	if err = writer.Flush(); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to compact wal[%s], err:%v", w.path, err)
+	}
+	if err = os.Rename(tmpPath, w.path); err != nil {
+		return fmt.Errorf("Failed to compact wal[%s], err:%v", w.path, err)
+	}
+	syncDir(filepath.Dir(w.path))
+
+	if w.file != nil {
+		w.file.Close()
+	}
+	w.file, err = os.OpenFile(w.path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to open wal[%s], err:%v", w.path, err)
+	}
+	w.dones = 0
+	return nil
+}
+
+// 未完成的任务数量
+func (w *wal) len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.pending)
+}
+
+// 压缩并关闭日志
+func (w *wal) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	var err error
+	if w.dones > 0 {
+		err = w.rewrite()
+	}
+	w.closed = true
+	if w.file != nil {
+		w.file.Close()
+		w.file = nil
+	}
+	return err
+}
+
+// 同步目录，保证重命名落盘
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	d.Close()
+}
@@ -0,0 +1,160 @@
+package workpool
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPoolWALReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "workpool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "jobs.wal")
+
+	started := make(chan struct{}, 1)
+	pool, err := NewWithOptions(Options{
+		Size:    1,
+		WALPath: path,
+		Handlers: map[string]Handler{
+			"ok": func(ctx context.Context, payload []byte) error {
+				return nil
+			},
+			"block": func(ctx context.Context, payload []byte) error {
+				started <- struct{}{}
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := pool.SubmitNamed(context.Background(), "ok", []byte("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = job.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	for _, payload := range []string{"b", "c"} {
+		if _, err = pool.SubmitNamed(context.Background(), "block", []byte(payload)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-started
+	if pending := pool.Pending(); pending != 2 {
+		t.Fatalf("Wrong pending, expect:2, got:%d", pending)
+	}
+	// 模拟进程退出：正在执行与排队中的任务均未完成
+	pool.Stop()
+
+	var (
+		mu       sync.Mutex
+		replayed []string
+	)
+	pool, err = NewWithOptions(Options{
+		Size:    2,
+		WALPath: path,
+		Handlers: map[string]Handler{
+			"ok": func(ctx context.Context, payload []byte) error {
+				return nil
+			},
+			"block": func(ctx context.Context, payload []byte) error {
+				mu.Lock()
+				replayed = append(replayed, string(payload))
+				mu.Unlock()
+				return nil
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for pool.Pending() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	pool.StopWait()
+	sort.Strings(replayed)
+	if strings.Join(replayed, ",") != "b,c" {
+		t.Fatalf("Wrong replayed jobs:%v", replayed)
+	}
+
+	// 全部完成后关闭时压缩为空文件
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content) != 0 {
+		t.Fatalf("Wal should be empty after compaction, got:%s", content)
+	}
+}
+
+func TestWALTornWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "workpool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "jobs.wal")
+	content := `{"op":"submit","id":1,"name":"a"}
+{"op":"submit","id":2,"name":"b"}
+{"op":"done","id":1}
+{"op":"submit","id":3,"na`
+	if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	log, records, err := openWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.close()
+	if len(records) != 1 || records[0].Id != 2 || records[0].Name != "b" {
+		t.Fatalf("Wrong records:%+v", records)
+	}
+	// 新任务编号接在已有记录之后
+	id, err := log.submit(&Job{Name: "c"})
+	if err != nil || id != 3 {
+		t.Fatalf("Wrong id:%d, err:%v", id, err)
+	}
+
+	if err = ioutil.WriteFile(path, []byte("bad\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = openWAL(path); err == nil {
+		t.Fatal("Corrupted wal should fail")
+	}
+}
+
+func TestPoolWALMissingHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "workpool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "jobs.wal")
+	if err = ioutil.WriteFile(path, []byte(`{"op":"submit","id":1,"name":"lost"}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewWithOptions(Options{Size: 1, WALPath: path}); err == nil {
+		t.Fatal("Unknown handler in wal should fail")
+	}
+
+	pool, err := NewWithOptions(Options{Size: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Stop()
+	if _, err = pool.SubmitNamed(context.Background(), "lost", nil); err == nil {
+		t.Fatal("Submit without handler should fail")
+	}
+}
@@ -1,45 +1,103 @@
 package workpool
 
 import (
+	"context"
 	"fmt"
 	"github.com/learning_golang/logger"
 	"math/rand"
+	"time"
 )
 
-// 计算数字
+// 任务：设置 Task 时执行通用任务，否则计算数字各位之和
 type Job struct {
 	Id     int
 	Number int
+	Task   Task
+	// 单次执行超时时间，为 0 时使用协程池默认值
+	Timeout time.Duration
+	// 重试策略，为空时使用协程池默认值
+	Retry *RetryPolicy
+	// 优先级，数值越大越先执行，需开启 Options.Priority
+	Priority int
+	// 租户，开启 Options.FairShare 时按租户轮询
+	Tenant string
+	// 处理函数名称与参数，设置 Name 时按 Options.Handlers 执行，开启预写日志后可在重启时恢复
+	Name    string
+	Payload []byte
+	// 限流 key，如下游主机，需设置 Options.KeyLimiter
+	RateKey string
+
+	ctx       context.Context
+	done      chan struct{}
+	err       error
+	errs      []error
+	attempts  int
+	limits    int
+	limitErr  error
+	submitted time.Time
+	seq       uint64
+	walId     uint64
+}
+
+// 等待任务完成，返回任务错误
+func (j *Job) Wait() error {
+	<-j.done
+	return j.err
+}
+
+// 任务完成时关闭的管道
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// 已执行次数，任务完成后读取
+func (j *Job) Attempts() int {
+	return j.attempts
+}
+
+// 每次执行失败的错误，任务完成后读取
+func (j *Job) Errors() []error {
+	errs := make([]error, len(j.errs))
+	copy(errs, j.errs)
+	return errs
 }
 
 // 计算结果
 type Result struct {
 	Job *Job
 	Sum int
+	Err error
 }
 
-// 实际业务处理
-func Progress(job *Job, retChan chan *Result) {
-	var (
-		sum    int
-		number int
-	)
-	number = job.Number
+// 计算数字各位之和
+func DigitSum(number int) int {
+	var sum int
 	for number != 0 {
 		sum = sum + number%10
 		number /= 10
 	}
+	return sum
+}
+
+// 实际业务处理
+func Progress(job *Job, retChan chan *Result) {
 	result := &Result{
 		Job: job,
-		Sum: sum,
+		Sum: DigitSum(job.Number),
 	}
 	retChan <- result
 }
 
-// 处理函数
+// 处理函数，任务 panic 时以错误结果返回
 func Worker(jobChan chan *Job, retChan chan *Result) {
 	for job := range jobChan {
-		Progress(job, retChan)
+		err := protect(func() error {
+			Progress(job, retChan)
+			return nil
+		})
+		if err != nil {
+			retChan <- &Result{Job: job, Err: err}
+		}
 	}
 }
 
@@ -52,32 +110,70 @@ func Workpool(workNum int, jobChan chan *Job, retChan chan *Result) {
 
 // 打印结果
 func PrintResult(retChan chan *Result) {
-	logConf := map[string]string{
-		"path":  "/Users/lsrong/Work/Project/Test",
-		"level": "debug",
-	}
-	log, _ := logger.NewFileLogger(logConf)
+	log, _ := logger.NewConsoleLogger(map[string]string{"level": "debug"})
 	for ret := range retChan {
 		job := ret.Job
-		fmt.Printf("Job:id=%d,number=%d; result=%d\n", job.Id, job.Number, ret.Sum)
-		log.Debug("Job:id=%d,number=%d; result=%d\n", job.Id, job.Number, ret.Sum)
+		if ret.Err != nil {
+			log.Error("Job:id=%d,number=%d; err=%v", job.Id, job.Number, ret.Err)
+			continue
+		}
+		log.Debug("Job:id=%d,number=%d; result=%d", job.Id, job.Number, ret.Sum)
 	}
 	log.Close()
 }
 
-func Start() {
-	jobChan := make(chan *Job, 1000)
-	retChan := make(chan *Result, 1000)
-	workNum := 64
-	Workpool(workNum, jobChan, retChan)
-	go PrintResult(retChan)
-	var id int
-	for {
-		id++
+// 打印协程池运行统计
+func PrintStats(stats Stats) {
+	fmt.Printf("Workpool: submitted=%d, running=%d, completed=%d, failed=%d, queued=%d, workers=%d, wait=%s, exec=%s\n",
+		stats.Submitted, stats.Running, stats.Completed, stats.Failed, stats.Queued, stats.Workers,
+		average(stats.Wait), average(stats.Exec))
+}
+
+// 平均耗时
+func average(h Histogram) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return time.Duration(h.Sum / float64(h.Count) * float64(time.Second))
+}
+
+// 提交 jobNum 个数字求和任务，执行期间每秒打印运行统计，全部完成后停止协程池
+func Start(jobNum int) {
+	pool, err := New(64)
+	if err != nil {
+		fmt.Printf("Failed to create workpool, err:%v\n", err)
+		return
+	}
+	printed := make(chan struct{})
+	go func() {
+		PrintResult(pool.retChan)
+		close(printed)
+	}()
+	stopped := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				PrintStats(pool.Stats())
+			case <-stopped:
+				return
+			}
+		}
+	}()
+	for id := 1; id <= jobNum; id++ {
 		job := &Job{
 			Id:     id,
 			Number: rand.Int(),
 		}
-		jobChan <- job
+		if err = pool.SubmitJob(context.Background(), job); err != nil {
+			fmt.Printf("Failed to submit job, err:%v\n", err)
+			break
+		}
 	}
+	pool.StopWait()
+	close(stopped)
+	<-printed
+	PrintStats(pool.Stats())
 }
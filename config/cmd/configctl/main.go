@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/learning_golang/config"
+	"github.com/urfave/cli"
+)
+
+// app.ini 对应的配置结构
+type AppConfig struct {
+	Redis Redis `ini:"redis"`
+	Mysql Mysql `ini:"mysql"`
+}
+
+type Redis struct {
+	Host     string        `ini:"host" default:"localhost" desc:"redis host"`
+	Port     int           `ini:"port" default:"6379" desc:"redis port"`
+	Password config.Secret `ini:"password" desc:"redis password"`
+}
+
+type Mysql struct {
+	Host     string        `ini:"host" default:"127.0.0.1" desc:"mysql host"`
+	Port     int           `ini:"port" default:"3306" desc:"mysql port"`
+	Username string        `ini:"username" default:"root" desc:"mysql username"`
+	Password config.Secret `ini:"password" desc:"mysql password"`
+	Database string        `ini:"database" desc:"mysql database name"`
+	Charset  string        `ini:"charset" default:"utf8mb4" desc:"mysql connection charset"`
+}
+
+func init() {
+	_ = config.Register("app", &AppConfig{})
+}
+
+// 按 --schema 参数创建配置结构体
+func schema(c *cli.Context) (interface{}, error) {
+	return config.NewSchema(c.GlobalString("schema"))
+}
+
+// 校验配置文件
+func validate(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("Usage: configctl validate <file>", 2)
+	}
+	s, err := schema(c)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 2)
+	}
+	if err = config.ValidateFile(s, c.Args().First()); err != nil {
+		return cli.NewExitError(fmt.Sprintf("%s: %v", c.Args().First(), err), 1)
+	}
+	fmt.Printf("%s: ok\n", c.Args().First())
+	return nil
+}
+
+// 输出合并默认值后的配置
+func dump(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("Usage: configctl dump <file>", 2)
+	}
+	s, err := schema(c)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 2)
+	}
+	if err = config.NewLoader().AddFile(c.Args().First()).Load(s); err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	if err = config.Dump(os.Stdout, s); err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	return nil
+}
+
+// 比较两份配置
+func diff(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return cli.NewExitError("Usage: configctl diff <old file> <new file>", 2)
+	}
+	s, err := schema(c)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 2)
+	}
+	changes, err := config.DiffFiles(s, c.Args().Get(0), c.Args().Get(1))
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	for _, change := range changes {
+		fmt.Printf("%s: %q => %q\n", change.Key, change.Old, change.New)
+	}
+	return nil
+}
+
+// 生成示例配置
+func sample(c *cli.Context) error {
+	s, err := schema(c)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 2)
+	}
+	if err = config.WriteSample(os.Stdout, s); err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	return nil
+}
+
+// 加密配置值，未传参数时从标准输入读取，避免明文出现在命令历史中
+func encrypt(c *cli.Context) error {
+	value := c.Args().First()
+	if c.NArg() == 0 {
+		reader := bufio.NewReader(os.Stdin)
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		value = strings.TrimRight(line, "\r\n")
+	}
+	key, err := config.LoadSecretKey()
+	if err != nil {
+		return cli.NewExitError(err.Error(), 2)
+	}
+	encrypted, err := config.Encrypt(key, value)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	fmt.Println(encrypted)
+	return nil
+}
+
+// 生成新的密钥
+func genkey(c *cli.Context) error {
+	key, err := config.GenerateSecretKey()
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	fmt.Println(key)
+	return nil
+}
+
+func main() {
+	app := cli.NewApp()
+	app.Name = "configctl"
+	app.Usage = "validate, dump, diff ini config files, generate samples and encrypt secrets"
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "schema, s",
+			Value: "app",
+			Usage: fmt.Sprintf("registered config schema %v", config.Schemas()),
+		},
+	}
+	app.Commands = []cli.Command{
+		{
+			Name:      "validate",
+			Usage:     "check that a config file matches the schema",
+			ArgsUsage: "<file>",
+			Action:    validate,
+		},
+		{
+			Name:      "dump",
+			Usage:     "print resolved values with secrets masked",
+			ArgsUsage: "<file>",
+			Action:    dump,
+		},
+		{
+			Name:      "diff",
+			Usage:     "compare two config files by resolved values",
+			ArgsUsage: "<old file> <new file>",
+			Action:    diff,
+		},
+		{
+			Name:   "sample",
+			Usage:  "generate a commented sample config from the schema",
+			Action: sample,
+		},
+		{
+			Name:      "encrypt",
+			Usage:     fmt.Sprintf("encrypt a value as ENC(...) with the key from %s or %s", config.KeyEnv, config.KeyFileEnv),
+			ArgsUsage: "[value]",
+			Action:    encrypt,
+		},
+		{
+			Name:   "genkey",
+			Usage:  "generate a base64 encoded AES-256 key",
+			Action: genkey,
+		},
+	}
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
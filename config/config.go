@@ -1,22 +1,103 @@
 package config
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 )
 
+// 配置校验接口，配置结构体实现后在解析完成时调用
+type Validator interface {
+	Validate() error
+}
+
 /*
 校验配置参数结构体
 读取文件，逐行解析文件内容
 分析结构体参数属性匹配配置项并赋值
 */
 func UnMarshalFile(filepath string, config interface{}) error {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return errors.New("Failed to read ini file!")
+	}
+	defer file.Close()
+
+	decoder := NewDecoder(file)
+	decoder.path = filepath
+	return decoder.Decode(config)
+}
+
+// 解析内存中的配置内容，include 的相对路径基于当前工作目录
+func Unmarshal(data []byte, config interface{}) error {
+	return NewDecoder(bytes.NewReader(data)).Decode(config)
+}
+
+// 配置解析器，从 io.Reader 读取配置内容
+type Decoder struct {
+	reader io.Reader
+	path   string
+}
+
+// 构造配置解析器
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{reader: r}
+}
+
+// 读取全部内容并赋值给配置结构体
+func (d *Decoder) Decode(config interface{}) error {
 	// 校验配置参数
+	if err := checkStructPtr(config); err != nil {
+		return err
+	}
+
+	content, err := ioutil.ReadAll(d.reader)
+	if err != nil {
+		return fmt.Errorf("Failed to read config, err:%v", err)
+	}
+	doc, err := readDocument(content, d.path)
+	if err != nil {
+		return err
+	}
+	return decode(doc, config)
+}
+
+// 解析配置内容：加载引入的文件、替换变量并解密，path 为空时 include 的相对路径基于当前工作目录
+func readDocument(content []byte, path string) (*document, error) {
+	l := &fileLoader{visiting: make(map[string]bool)}
+	var dir string
+	if len(path) > 0 {
+		// 文件内容同样参与循环引入检测
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		l.visiting[absPath] = true
+		dir = filepath.Dir(absPath)
+	}
+	doc, err := l.loadContent(content, path, dir)
+	if err != nil {
+		return nil, err
+	}
+	if err = interpolate(doc); err != nil {
+		return nil, err
+	}
+	if err = decryptDocument(doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// 校验结构体指针
+func checkStructPtr(config interface{}) error {
 	typeInfo := reflect.TypeOf(config)
-	if typeInfo.Kind() != reflect.Ptr {
+	if typeInfo == nil || typeInfo.Kind() != reflect.Ptr {
 		// 指针类型校验
 		return errors.New("Please enter point args")
 	}
@@ -25,19 +106,21 @@ func UnMarshalFile(filepath string, config interface{}) error {
 		// 结构体校验
 		return errors.New("Please enter struct args")
 	}
+	return nil
+}
 
-	// 读取文件
-	content, err := ioutil.ReadFile(filepath)
-	if err != nil {
-		return errors.New("Failed to read ini file!")
+// 结构体字段对应的配置名称，ini 标签优先，"-" 表示忽略
+func fieldName(field reflect.StructField) (string, bool) {
+	if field.PkgPath != "" {
+		// 非导出字段
+		return "", false
 	}
-	//fmt.Println(string(content))
-	lines := strings.Split(string(content), "\n")
-	for index, line := range lines {
-		// TODO 读取每一行内容，寻找配置节点 => 具体赋值
-		fmt.Println(index)
-		fmt.Println(line)
+	name := field.Tag.Get("ini")
+	if name == "-" {
+		return "", false
 	}
-
-	return nil
+	if len(name) == 0 {
+		name = strings.ToLower(field.Name)
+	}
+	return name, true
 }
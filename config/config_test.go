@@ -1,6 +1,7 @@
 package config
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -40,6 +41,30 @@ type Mysql struct {
 func TestUnMarshalFile(t *testing.T) {
 	path := "./app.ini"
 	var config Config
-	_ = UnMarshalFile(path, &config)
+	if err := UnMarshalFile(path, &config); err != nil {
+		t.Fatalf("UnMarshalFile failed, err:%v", err)
+	}
+	if config.Redis.Port != 6379 || config.Mysql.Database != "golang" {
+		t.Fatalf("UnMarshalFile wrong value, config:%#v", config)
+	}
 	t.Logf("UnMarshalFile success, config:%#v", config)
 }
+
+func TestUnmarshal(t *testing.T) {
+	data := []byte("[redis]\nhost=10.0.0.1\nport=6380\n\n[mysql]\nhost=${redis.host}\n")
+	var config Config
+	if err := Unmarshal(data, &config); err != nil {
+		t.Fatalf("Unmarshal failed, err:%v", err)
+	}
+	if config.Redis.Port != 6380 || config.Mysql.Host != "10.0.0.1" {
+		t.Fatalf("Unmarshal wrong value, config:%#v", config)
+	}
+
+	var other Config
+	if err := NewDecoder(strings.NewReader("[redis]\nport=abc\n")).Decode(&other); err == nil {
+		t.Fatal("Decode invalid port should fail")
+	}
+	if err := Unmarshal(data, config); err == nil {
+		t.Fatal("Unmarshal into non-pointer should fail")
+	}
+}
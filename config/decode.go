@@ -0,0 +1,225 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// 是否对应配置节点：结构体或结构体指针，时间与自定义文本类型除外
+func isSection(typeInfo reflect.Type) bool {
+	if typeInfo.Kind() == reflect.Ptr {
+		typeInfo = typeInfo.Elem()
+	}
+	if typeInfo.Kind() != reflect.Struct || typeInfo == timeType {
+		return false
+	}
+	return !reflect.PtrTo(typeInfo).Implements(textUnmarshalerType)
+}
+
+// 指针字段为空时分配内存，返回指向的值
+func indirect(value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+// 时间字段的格式，通过 layout 标签指定
+func timeLayout(field reflect.StructField) string {
+	layout := field.Tag.Get("layout")
+	if len(layout) == 0 {
+		layout = time.RFC3339
+	}
+	return layout
+}
+
+// 将解析结果赋值给结构体：外层字段对应节点，内层字段对应配置项
+func decode(doc *document, config interface{}) error {
+	if err := decodeStruct(doc, "", reflect.ValueOf(config).Elem()); err != nil {
+		return err
+	}
+
+	if validator, ok := config.(Validator); ok {
+		return validator.Validate()
+	}
+	return nil
+}
+
+/*
+按字段递归赋值：
+结构体字段对应节点，嵌套结构体对应 [parent.child] 子节点，其余字段对应节点内的配置项
+*/
+func decodeStruct(doc *document, prefix string, value reflect.Value) error {
+	typeInfo := value.Type()
+	sec := doc.Section(prefix)
+	for i := 0; i < typeInfo.NumField(); i++ {
+		field := typeInfo.Field(i)
+		name, ok := fieldName(field)
+		if !ok {
+			continue
+		}
+		path := name
+		if len(prefix) > 0 {
+			path = prefix + "." + name
+		}
+
+		if isSection(field.Type) {
+			if !doc.hasSection(path) {
+				continue
+			}
+			if err := decodeStruct(doc, path, indirect(value.Field(i))); err != nil {
+				return err
+			}
+			continue
+		}
+		if sec == nil {
+			continue
+		}
+		if err := decodeField(sec, name, field, value.Field(i)); err != nil {
+			return fmt.Errorf("Failed to set %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// 节点内配置项赋值，支持切片与 key.sub 形式的映射
+func decodeField(sec *section, name string, field reflect.StructField, value reflect.Value) error {
+	layout := timeLayout(field)
+	typeInfo := field.Type
+	for typeInfo.Kind() == reflect.Ptr {
+		typeInfo = typeInfo.Elem()
+	}
+
+	switch {
+	case typeInfo.Kind() == reflect.Map:
+		keyPrefix := name + "."
+		var entries []*entry
+		for _, e := range sec.Entries {
+			if strings.HasPrefix(e.Key, keyPrefix) {
+				entries = append(entries, e)
+			}
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		value = indirect(value)
+		if value.IsNil() {
+			value.Set(reflect.MakeMap(typeInfo))
+		}
+		for _, e := range entries {
+			key := reflect.New(typeInfo.Key()).Elem()
+			if err := setValue(key, strings.TrimPrefix(e.Key, keyPrefix), layout); err != nil {
+				return err
+			}
+			elem := reflect.New(typeInfo.Elem()).Elem()
+			if err := setValue(elem, e.Value, layout); err != nil {
+				return err
+			}
+			value.SetMapIndex(key, elem)
+		}
+	case typeInfo.Kind() == reflect.Slice && typeInfo.Elem().Kind() != reflect.Uint8:
+		raws := sec.Values(name)
+		if len(raws) == 0 {
+			return nil
+		}
+		if len(raws) == 1 {
+			// 单个配置项按逗号分隔
+			raws = strings.Split(raws[0], ",")
+		}
+		slice := reflect.MakeSlice(typeInfo, 0, len(raws))
+		for _, raw := range raws {
+			raw = strings.TrimSpace(raw)
+			if len(raw) == 0 {
+				continue
+			}
+			elem := reflect.New(typeInfo.Elem()).Elem()
+			if err := setValue(elem, raw, layout); err != nil {
+				return err
+			}
+			slice = reflect.Append(slice, elem)
+		}
+		indirect(value).Set(slice)
+	default:
+		raw, found := sec.Get(name)
+		if !found {
+			return nil
+		}
+		return setValue(value, raw, layout)
+	}
+	return nil
+}
+
+// 按字段类型转换字符串
+func setValue(value reflect.Value, raw string, layout string) error {
+	value = indirect(value)
+	typeInfo := value.Type()
+	switch {
+	case typeInfo == timeType:
+		t, err := time.Parse(layout, raw)
+		if err != nil {
+			return err
+		}
+		value.Set(reflect.ValueOf(t))
+		return nil
+	case typeInfo == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+		return nil
+	case reflect.PtrTo(typeInfo).Implements(textUnmarshalerType):
+		return value.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, err := strconv.ParseInt(raw, 10, typeInfo.Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(number)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number, err := strconv.ParseUint(raw, 10, typeInfo.Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(number)
+	case reflect.Float32, reflect.Float64:
+		number, err := strconv.ParseFloat(raw, typeInfo.Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(number)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Slice:
+		// 只支持 []byte，其他切片在 decodeField 中按逗号拆分，嵌套切片不支持
+		if typeInfo.Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported type %s", typeInfo)
+		}
+		value.SetBytes([]byte(raw))
+	default:
+		return fmt.Errorf("unsupported type %s", typeInfo)
+	}
+	return nil
+}
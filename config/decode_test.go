@@ -0,0 +1,126 @@
+package config
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+type ServerConfig struct {
+	Server  Server   `ini:"server"`
+	Cluster *Cluster `ini:"cluster"`
+	Missing *Server  `ini:"missing"`
+}
+
+type Server struct {
+	Hosts    []string          `ini:"hosts"`
+	Ports    []int             `ini:"ports"`
+	Timeout  time.Duration     `ini:"timeout"`
+	Started  time.Time         `ini:"started" layout:"2006-01-02"`
+	Labels   map[string]string `ini:"labels"`
+	Ip       net.IP            `ini:"ip"`
+	MaxConns *int              `ini:"max_conns"`
+}
+
+type Cluster struct {
+	Name    string  `ini:"name"`
+	Replica Replica `ini:"replica"`
+}
+
+type Replica struct {
+	Host string `ini:"host"`
+	Port int    `ini:"port"`
+}
+
+const serverIni = `
+[server]
+hosts=10.0.0.1, 10.0.0.2
+ports=80
+ports=443
+timeout=30s
+started=2020-08-20
+labels.env=prod
+labels.zone=sh
+ip=192.168.1.1
+max_conns=100
+
+[cluster]
+name=main
+
+[cluster.replica]
+host=10.0.0.3
+port=3307
+`
+
+func TestDecodeRichTypes(t *testing.T) {
+	doc, err := parse([]byte(serverIni))
+	if err != nil {
+		t.Fatalf("Parse failed, err:%v", err)
+	}
+	var config ServerConfig
+	if err = decode(doc, &config); err != nil {
+		t.Fatalf("Decode failed, err:%v", err)
+	}
+	server := config.Server
+	if len(server.Hosts) != 2 || server.Hosts[1] != "10.0.0.2" {
+		t.Fatalf("Wrong hosts:%#v", server.Hosts)
+	}
+	if len(server.Ports) != 2 || server.Ports[0] != 80 || server.Ports[1] != 443 {
+		t.Fatalf("Wrong ports:%#v", server.Ports)
+	}
+	if server.Timeout != 30*time.Second {
+		t.Fatalf("Wrong timeout:%v", server.Timeout)
+	}
+	if server.Started.Format("2006-01-02") != "2020-08-20" {
+		t.Fatalf("Wrong started:%v", server.Started)
+	}
+	if server.Labels["env"] != "prod" || server.Labels["zone"] != "sh" {
+		t.Fatalf("Wrong labels:%#v", server.Labels)
+	}
+	if !server.Ip.Equal(net.ParseIP("192.168.1.1")) {
+		t.Fatalf("Wrong ip:%v", server.Ip)
+	}
+	if server.MaxConns == nil || *server.MaxConns != 100 {
+		t.Fatalf("Wrong max_conns:%v", server.MaxConns)
+	}
+	if config.Cluster == nil || config.Cluster.Name != "main" || config.Cluster.Replica.Port != 3307 {
+		t.Fatalf("Wrong cluster:%#v", config.Cluster)
+	}
+	if config.Missing != nil {
+		t.Fatalf("Missing section should stay nil")
+	}
+
+	values, _ := flatten(&config)
+	if values["server.timeout"] != "30s" || values["server.labels.env"] != "prod" || values["cluster.replica.host"] != "10.0.0.3" {
+		t.Fatalf("Wrong flatten values:%#v", values)
+	}
+}
+
+func TestDecodeInvalidValue(t *testing.T) {
+	doc, _ := parse([]byte("[server]\ntimeout=abc\n"))
+	var config ServerConfig
+	if err := decode(doc, &config); err == nil {
+		t.Fatal("Invalid duration should fail")
+	}
+}
+
+func TestDecodeUnsupportedSlice(t *testing.T) {
+	doc, _ := parse([]byte("[server]\nhosts.a=x,y\nmatrix=1,2\n"))
+	var mapConfig struct {
+		Server struct {
+			Hosts map[string][]string `ini:"hosts"`
+		} `ini:"server"`
+	}
+	if err := decode(doc, &mapConfig); err == nil || !strings.Contains(err.Error(), "unsupported type") {
+		t.Fatalf("Wrong error:%v", err)
+	}
+	var sliceConfig struct {
+		Server struct {
+			Matrix [][]int `ini:"matrix"`
+		} `ini:"server"`
+	}
+	if err := decode(doc, &sliceConfig); err == nil || !strings.Contains(err.Error(), "unsupported type") {
+		t.Fatalf("Wrong error:%v", err)
+	}
+}
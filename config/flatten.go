@@ -0,0 +1,113 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+/*
+将配置结构体展开为 section.key => value，用于比较与输出，
+secrets 为敏感字段展开后的配置名，输出前需以掩码代替
+*/
+func flatten(config interface{}) (values map[string]string, secrets map[string]bool) {
+	values = make(map[string]string)
+	secrets = make(map[string]bool)
+	value := reflect.Indirect(reflect.ValueOf(config))
+	if value.Kind() != reflect.Struct {
+		return values, secrets
+	}
+	flattenStruct("", value, values, secrets)
+	return values, secrets
+}
+
+// 递归展开结构体，映射字段展开为 section.key.sub
+func flattenStruct(prefix string, value reflect.Value, values map[string]string, secrets map[string]bool) {
+	typeInfo := value.Type()
+	for i := 0; i < typeInfo.NumField(); i++ {
+		field := typeInfo.Field(i)
+		name, ok := fieldName(field)
+		if !ok {
+			continue
+		}
+		path := name
+		if len(prefix) > 0 {
+			path = prefix + "." + name
+		}
+		fieldValue := value.Field(i)
+		for fieldValue.Kind() == reflect.Ptr {
+			if fieldValue.IsNil() {
+				break
+			}
+			fieldValue = fieldValue.Elem()
+		}
+		if fieldValue.Kind() == reflect.Ptr {
+			continue
+		}
+
+		if isSection(field.Type) {
+			flattenStruct(path, fieldValue, values, secrets)
+			continue
+		}
+		if len(prefix) == 0 {
+			// 节点外的字段不对应配置项
+			continue
+		}
+		layout := timeLayout(field)
+		secret := isSecret(field)
+		switch {
+		case fieldValue.Kind() == reflect.Map:
+			iter := fieldValue.MapRange()
+			for iter.Next() {
+				key := path + "." + formatValue(iter.Key(), layout)
+				values[key] = formatValue(iter.Value(), layout)
+				if secret {
+					secrets[key] = true
+				}
+			}
+			continue
+		case fieldValue.Kind() == reflect.Slice && fieldValue.Type().Elem().Kind() != reflect.Uint8:
+			items := make([]string, 0, fieldValue.Len())
+			for j := 0; j < fieldValue.Len(); j++ {
+				items = append(items, formatValue(fieldValue.Index(j), layout))
+			}
+			values[path] = strings.Join(items, ",")
+		default:
+			values[path] = formatValue(fieldValue, layout)
+		}
+		if secret {
+			secrets[path] = true
+		}
+	}
+}
+
+// 将字段值格式化为配置文件中的写法
+func formatValue(value reflect.Value, layout string) string {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+	switch {
+	case value.Type() == timeType:
+		return value.Interface().(time.Time).Format(layout)
+	case value.Type() == durationType:
+		return time.Duration(value.Int()).String()
+	case value.Type().Implements(textMarshalerType):
+		text, err := value.Interface().(encoding.TextMarshaler).MarshalText()
+		if err == nil {
+			return string(text)
+		}
+	case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8:
+		return string(value.Bytes())
+	case value.Kind() == reflect.String:
+		// 取原始值，Secret 的 String 只返回掩码
+		return value.String()
+	}
+	return fmt.Sprint(value.Interface())
+}
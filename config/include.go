@@ -0,0 +1,116 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// 加载配置文件及其引入的文件
+type fileLoader struct {
+	visiting map[string]bool
+	content  bytes.Buffer
+}
+
+/*
+读取配置文件：
+先按顺序加载 include 引入的文件（相对路径基于当前文件目录），再合并当前文件，
+同名配置项以后加载的为准，最后替换 ${...} 变量并解密 ENC(...) 配置值。
+返回的内容为所有读取过的文件拼接，用于判断配置是否变化
+*/
+func loadFile(path string) (*document, []byte, error) {
+	doc, content, err := loadRaw(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = interpolate(doc); err != nil {
+		return nil, nil, err
+	}
+	if err = decryptDocument(doc); err != nil {
+		return nil, nil, err
+	}
+	return doc, content, nil
+}
+
+// 读取配置文件及其引入的文件，不替换变量
+func loadRaw(path string) (*document, []byte, error) {
+	l := &fileLoader{visiting: make(map[string]bool)}
+	doc, err := l.load(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, l.content.Bytes(), nil
+}
+
+// 递归加载，visiting 记录当前引入链用于检测循环引入
+func (l *fileLoader) load(path string) (*document, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if l.visiting[absPath] {
+		return nil, fmt.Errorf("Include cycle detected: %s", path)
+	}
+	l.visiting[absPath] = true
+	defer delete(l.visiting, absPath)
+
+	content, err := ioutil.ReadFile(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read ini file[%s]", path)
+	}
+	return l.loadContent(content, path, filepath.Dir(absPath))
+}
+
+// 解析配置内容并加载其引入的文件，name 为文件名（来自 io.Reader 时为空），dir 为相对路径引入的基准目录
+func (l *fileLoader) loadContent(content []byte, name string, dir string) (*document, error) {
+	l.content.Write(content)
+	doc, err := parse(content)
+	if err != nil {
+		if len(name) == 0 {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	source := "reader"
+	if len(name) > 0 {
+		source = "file:" + name
+	}
+	for _, sec := range doc.Sections {
+		for _, e := range sec.Entries {
+			e.Source = source
+		}
+	}
+
+	result := &document{}
+	for _, include := range doc.Includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(dir, include)
+		}
+		sub, err := l.load(include)
+		if err != nil {
+			return nil, err
+		}
+		merge(result, sub)
+	}
+	merge(result, doc)
+	return result, nil
+}
+
+// 合并配置文档，src 中出现的配置项整体覆盖 dst 中的同名项
+func merge(dst, src *document) {
+	for _, s := range src.Sections {
+		sec := dst.addSection(s.Name)
+		keys := make(map[string]bool)
+		for _, e := range s.Entries {
+			keys[e.Key] = true
+		}
+		entries := sec.Entries[:0]
+		for _, e := range sec.Entries {
+			if !keys[e.Key] {
+				entries = append(entries, e)
+			}
+		}
+		sec.Entries = append(entries, s.Entries...)
+	}
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIncludeAndInterpolate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = os.Mkdir(filepath.Join(dir, "shared"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "shared", "db.ini"), "[mysql]\nhost=10.0.0.1\nport=3306\nusername=root\npassword=${ENV:CONFIG_TEST_PASSWORD}\n")
+	writeFile(t, filepath.Join(dir, "app.ini"), "include = shared/db.ini\n\n[mysql]\nport=${ENV:CONFIG_TEST_PORT:-3307}\ndatabase=${mysql.username}_db\n\n[redis]\nhost=${mysql.host}\n")
+	if err = os.Setenv("CONFIG_TEST_PASSWORD", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("CONFIG_TEST_PASSWORD")
+
+	var config Config
+	if err = UnMarshalFile(filepath.Join(dir, "app.ini"), &config); err != nil {
+		t.Fatalf("UnMarshalFile failed, err:%v", err)
+	}
+	if config.Mysql.Host != "10.0.0.1" || config.Mysql.Port != 3307 || config.Mysql.Password != "secret" {
+		t.Fatalf("Wrong mysql config:%#v", config.Mysql)
+	}
+	if config.Mysql.Database != "root_db" || config.Redis.Host != "10.0.0.1" {
+		t.Fatalf("Wrong interpolation, config:%#v", config)
+	}
+}
+
+func TestIncludeCycle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeFile(t, filepath.Join(dir, "a.ini"), "include=b.ini\n[redis]\nhost=a\n")
+	writeFile(t, filepath.Join(dir, "b.ini"), "include=a.ini\n[redis]\nhost=b\n")
+
+	var config Config
+	err = UnMarshalFile(filepath.Join(dir, "a.ini"), &config)
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("Include cycle not detected, err:%v", err)
+	}
+}
+
+func TestInterpolateErrors(t *testing.T) {
+	cases := []string{
+		"[redis]\nhost=${redis.port}\nport=${redis.host}\n",
+		"[redis]\nhost=${mysql.host}\n",
+		"[redis]\nhost=${redis.port\n",
+	}
+	for _, content := range cases {
+		doc, err := parse([]byte(content))
+		if err != nil {
+			t.Fatalf("Parse failed, err:%v", err)
+		}
+		if err = interpolate(doc); err == nil {
+			t.Fatalf("Interpolate should fail, content:%q", content)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 敏感配置的掩码
+const SecretMask = "******"
+
+var (
+	schemaMu sync.RWMutex
+	schemas  = make(map[string]reflect.Type)
+)
+
+// 注册配置结构体，供命令行工具按名称校验、输出与生成示例
+func Register(name string, schema interface{}) error {
+	if err := checkStructPtr(schema); err != nil {
+		return err
+	}
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	if _, ok := schemas[name]; ok {
+		return fmt.Errorf("Schema %s already registered", name)
+	}
+	schemas[name] = reflect.TypeOf(schema).Elem()
+	return nil
+}
+
+// 按名称创建配置结构体指针
+func NewSchema(name string) (interface{}, error) {
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
+	typeInfo, ok := schemas[name]
+	if !ok {
+		return nil, fmt.Errorf("Schema %s not registered", name)
+	}
+	return reflect.New(typeInfo).Interface(), nil
+}
+
+// 已注册的配置名称
+func Schemas() []string {
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 是否为敏感配置，Secret 类型或通过 secret:"true" 标签指定
+func (f *fieldInfo) IsSecret() bool {
+	return isSecret(f.Field)
+}
+
+// 配置项的类型说明
+func (f *fieldInfo) TypeName() string {
+	typeInfo := f.Field.Type
+	for typeInfo.Kind() == reflect.Ptr {
+		typeInfo = typeInfo.Elem()
+	}
+	switch {
+	case typeInfo == durationType:
+		return "duration"
+	case typeInfo == timeType:
+		return "time(" + timeLayout(f.Field) + ")"
+	case typeInfo.Kind() == reflect.Map:
+		return "map[" + typeInfo.Key().String() + "]" + typeInfo.Elem().String()
+	case typeInfo.Kind() == reflect.Slice && typeInfo.Elem().Kind() != reflect.Uint8:
+		return "list of " + typeInfo.Elem().String()
+	}
+	return typeInfo.String()
+}
+
+/*
+校验配置文件：
+文件能按结构体解析并通过 Validate，且不包含结构体中未定义的节点与配置项
+*/
+func ValidateFile(schema interface{}, path string) error {
+	if err := checkStructPtr(schema); err != nil {
+		return err
+	}
+	doc, _, err := loadFile(path)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[string]bool)
+	mapKeys := make(map[string]bool)
+	for _, info := range fields(reflect.TypeOf(schema)) {
+		known[info.Path()] = true
+		if info.IsMap() {
+			mapKeys[info.Path()] = true
+		}
+	}
+	var unknown []string
+	for _, sec := range doc.Sections {
+		for _, e := range sec.Entries {
+			key := sec.Name + "." + e.Key
+			if known[key] {
+				continue
+			}
+			if index := strings.Index(e.Key, "."); index != -1 && mapKeys[sec.Name+"."+e.Key[:index]] {
+				continue
+			}
+			unknown = append(unknown, fmt.Sprintf("%s (line:%d)", key, e.Line))
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("Unknown config keys: %s", strings.Join(unknown, ", "))
+	}
+
+	return NewLoader().AddFile(path).Load(reflect.New(reflect.TypeOf(schema).Elem()).Interface())
+}
+
+// 按结构体定义顺序输出 ini 格式的配置，敏感配置以掩码代替
+func Dump(w io.Writer, config interface{}) error {
+	if err := checkStructPtr(config); err != nil {
+		return err
+	}
+	values, secrets := flatten(config)
+	current := ""
+	for _, info := range fields(reflect.TypeOf(config)) {
+		var lines []string
+		if info.IsMap() {
+			var keys []string
+			for key := range values {
+				if strings.HasPrefix(key, info.Path()+".") {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				lines = append(lines, fmt.Sprintf("%s=%s", strings.TrimPrefix(key, info.Section+"."), maskValue(secrets[key], values[key])))
+			}
+		} else if value, ok := values[info.Path()]; ok {
+			lines = append(lines, fmt.Sprintf("%s=%s", info.Key, maskValue(secrets[info.Path()], value)))
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		if info.Section != current {
+			if len(current) > 0 {
+				if _, err := fmt.Fprintln(w); err != nil {
+					return err
+				}
+			}
+			current = info.Section
+			if _, err := fmt.Fprintf(w, "[%s]\n", current); err != nil {
+				return err
+			}
+		}
+		for _, line := range lines {
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// 敏感配置掩码
+func maskValue(secret bool, value string) string {
+	if secret && len(value) > 0 {
+		return SecretMask
+	}
+	return value
+}
+
+// 按结构体解析两份配置文件（含默认值）并比较，敏感配置以掩码代替
+func DiffFiles(schema interface{}, oldPath, newPath string) ([]Change, error) {
+	if err := checkStructPtr(schema); err != nil {
+		return nil, err
+	}
+	typeInfo := reflect.TypeOf(schema).Elem()
+	oldConfig := reflect.New(typeInfo).Interface()
+	if err := NewLoader().AddFile(oldPath).Load(oldConfig); err != nil {
+		return nil, err
+	}
+	newConfig := reflect.New(typeInfo).Interface()
+	if err := NewLoader().AddFile(newPath).Load(newConfig); err != nil {
+		return nil, err
+	}
+
+	return Diff(oldConfig, newConfig), nil
+}
+
+// 根据结构体标签生成带注释的示例配置，包含类型、默认值与说明
+func WriteSample(w io.Writer, schema interface{}) error {
+	if err := checkStructPtr(schema); err != nil {
+		return err
+	}
+	current := ""
+	for _, info := range fields(reflect.TypeOf(schema)) {
+		if info.Section != current {
+			if len(current) > 0 {
+				if _, err := fmt.Fprintln(w); err != nil {
+					return err
+				}
+			}
+			current = info.Section
+			if _, err := fmt.Fprintf(w, "[%s]\n", current); err != nil {
+				return err
+			}
+		}
+		if desc := info.Desc(); len(desc) > 0 {
+			if _, err := fmt.Fprintf(w, "; %s\n", desc); err != nil {
+				return err
+			}
+		}
+		comment := "; type: " + info.TypeName()
+		if _, ok := info.Field.Tag.Lookup("default"); ok {
+			comment += ", default: " + info.Default()
+		}
+		if info.IsSecret() {
+			comment += ", secret"
+		}
+		if _, err := fmt.Fprintln(w, comment); err != nil {
+			return err
+		}
+
+		// 没有默认值的配置项注释掉，保证示例文件可以直接通过校验
+		line := fmt.Sprintf("%s=%s", info.Key, info.Default())
+		if info.IsMap() {
+			line = fmt.Sprintf(";%s.name=", info.Key)
+		} else if _, ok := info.Field.Tag.Lookup("default"); !ok {
+			line = ";" + line
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type SecretConfig struct {
+	Mysql SecretMysql `ini:"mysql"`
+}
+
+type SecretMysql struct {
+	Host     string            `ini:"host" default:"127.0.0.1" desc:"mysql host"`
+	Port     int               `ini:"port" default:"3306"`
+	Password Secret            `ini:"password"`
+	Options  map[string]string `ini:"options"`
+}
+
+func TestValidateFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	good := filepath.Join(dir, "good.ini")
+	writeFile(t, good, "[mysql]\nhost=db\noptions.charset=utf8\n")
+	if err = ValidateFile(&SecretConfig{}, good); err != nil {
+		t.Fatalf("ValidateFile failed, err:%v", err)
+	}
+	bad := filepath.Join(dir, "bad.ini")
+	writeFile(t, bad, "[mysql]\nhots=db\n")
+	if err = ValidateFile(&SecretConfig{}, bad); err == nil || !strings.Contains(err.Error(), "mysql.hots") {
+		t.Fatalf("Unknown key not reported, err:%v", err)
+	}
+}
+
+func TestDumpAndDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	oldPath := filepath.Join(dir, "old.ini")
+	newPath := filepath.Join(dir, "new.ini")
+	writeFile(t, oldPath, "[mysql]\nhost=db\npassword=root\n")
+	writeFile(t, newPath, "; same host\n[mysql]\nhost = db\npassword=admin\nport=3307\n")
+
+	var config SecretConfig
+	if err = NewLoader().AddFile(oldPath).Load(&config); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err = Dump(&buf, &config); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "root") || !strings.Contains(buf.String(), "password="+SecretMask) {
+		t.Fatalf("Secret not masked:\n%s", buf.String())
+	}
+
+	changes, err := DiffFiles(&SecretConfig{}, oldPath, newPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].Key != "mysql.password" || changes[0].New != SecretMask || changes[1].Key != "mysql.port" {
+		t.Fatalf("Wrong changes:%#v", changes)
+	}
+}
+
+func TestWriteSample(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSample(&buf, &SecretConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	sample := buf.String()
+	for _, expected := range []string{"[mysql]", "; mysql host", "; type: int, default: 3306", "port=3306", ";password="} {
+		if !strings.Contains(sample, expected) {
+			t.Fatalf("Sample missing %q:\n%s", expected, sample)
+		}
+	}
+	doc, err := parse(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Sample not parsable, err:%v", err)
+	}
+	var config SecretConfig
+	if err = decode(doc, &config); err != nil || config.Mysql.Port != 3306 {
+		t.Fatalf("Sample decode failed, err:%v", err)
+	}
+}
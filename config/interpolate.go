@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	envPrefix      = "ENV:"
+	defaultSep     = ":-"
+	variableBegin  = "${"
+	variableFinish = "}"
+)
+
+/*
+替换配置值中的变量：
+${section.key} 引用其他配置项，${ENV:NAME} 读取环境变量，
+${ENV:PORT:-8080} 与 ${section.key:-value} 在未设置时使用默认值
+*/
+func interpolate(doc *document) error {
+	return interpolateWith(doc, true)
+}
+
+// 替换配置值中的变量，allowEnv 为 false 时 ${ENV:...} 直接报错，用于不可信的配置来源
+func interpolateWith(doc *document, allowEnv bool) error {
+	for _, sec := range doc.Sections {
+		for _, e := range sec.Entries {
+			value, err := expand(doc, e.Value, map[string]bool{sec.Name + "." + e.Key: true}, allowEnv)
+			if err != nil {
+				return fmt.Errorf("Failed to interpolate %s.%s, line:%d: %v", sec.Name, e.Key, e.Line, err)
+			}
+			e.Value = value
+		}
+	}
+	return nil
+}
+
+// 展开单个值，stack 记录引用链用于检测循环引用
+func expand(doc *document, value string, stack map[string]bool, allowEnv bool) (string, error) {
+	var builder strings.Builder
+	for {
+		begin := strings.Index(value, variableBegin)
+		if begin == -1 {
+			builder.WriteString(value)
+			return builder.String(), nil
+		}
+		finish := strings.Index(value[begin:], variableFinish)
+		if finish == -1 {
+			return "", fmt.Errorf("unterminated variable %q", value[begin:])
+		}
+		finish += begin
+		builder.WriteString(value[:begin])
+
+		resolved, err := resolve(doc, value[begin+len(variableBegin):finish], stack, allowEnv)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteString(resolved)
+		value = value[finish+len(variableFinish):]
+	}
+}
+
+// 解析变量表达式
+func resolve(doc *document, expr string, stack map[string]bool, allowEnv bool) (string, error) {
+	name, def, hasDefault := expr, "", false
+	if index := strings.Index(expr, defaultSep); index != -1 {
+		name, def, hasDefault = expr[:index], expr[index+len(defaultSep):], true
+	}
+
+	// 环境变量
+	if strings.HasPrefix(name, envPrefix) {
+		if !allowEnv {
+			return "", fmt.Errorf("environment variable ${%s} is not allowed here", expr)
+		}
+		value, ok := os.LookupEnv(strings.TrimPrefix(name, envPrefix))
+		if (!ok || len(value) == 0) && hasDefault {
+			return def, nil
+		}
+		if !ok {
+			return "", fmt.Errorf("environment variable %s not set", strings.TrimPrefix(name, envPrefix))
+		}
+		return value, nil
+	}
+
+	// 其他配置项
+	index := strings.LastIndex(name, ".")
+	if index <= 0 || index == len(name)-1 {
+		return "", fmt.Errorf("invalid reference ${%s}", expr)
+	}
+	if stack[name] {
+		return "", fmt.Errorf("reference cycle detected at ${%s}", name)
+	}
+	var (
+		raw   string
+		found bool
+	)
+	if sec := doc.Section(name[:index]); sec != nil {
+		raw, found = sec.Get(name[index+1:])
+	}
+	if !found {
+		if hasDefault {
+			return def, nil
+		}
+		return "", fmt.Errorf("undefined reference ${%s}", name)
+	}
+
+	stack[name] = true
+	defer delete(stack, name)
+	return expand(doc, raw, stack, allowEnv)
+}
@@ -0,0 +1,241 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// 默认值来源
+const SourceDefault = "default"
+
+/*
+分层加载配置，优先级从低到高：
+default 标签默认值 < 配置文件（按添加顺序） < 环境变量 < 命令行参数，
+合并后统一替换变量并赋值，同时记录每个配置项的最终来源
+*/
+type Loader struct {
+	files      []string
+	useEnv     bool
+	envPrefix  string
+	flagSet    *flag.FlagSet
+	cliContext *cli.Context
+	sources    map[string]string
+}
+
+// 构造分层加载器
+func NewLoader() *Loader {
+	return &Loader{
+		sources: make(map[string]string),
+	}
+}
+
+// 添加配置文件，后添加的优先级更高
+func (l *Loader) AddFile(paths ...string) *Loader {
+	l.files = append(l.files, paths...)
+	return l
+}
+
+// 读取环境变量，变量名为 PREFIX_SECTION_KEY 形式
+func (l *Loader) Env(prefix string) *Loader {
+	l.useEnv = true
+	l.envPrefix = prefix
+	return l
+}
+
+// 读取标准库命令行参数，需已调用 DefineFlags 并完成 Parse
+func (l *Loader) FlagSet(fs *flag.FlagSet) *Loader {
+	l.flagSet = fs
+	return l
+}
+
+// 读取 urfave/cli 命令行参数，需使用 CliFlags 生成的参数
+func (l *Loader) CliContext(c *cli.Context) *Loader {
+	l.cliContext = c
+	return l
+}
+
+// 合并所有来源并赋值给配置结构体
+func (l *Loader) Load(config interface{}) error {
+	if err := checkStructPtr(config); err != nil {
+		return err
+	}
+	infos := fields(reflect.TypeOf(config))
+
+	doc := &document{}
+	merge(doc, defaultDocument(infos))
+	for _, path := range l.files {
+		fileDoc, _, err := loadRaw(path)
+		if err != nil {
+			return err
+		}
+		merge(doc, fileDoc)
+	}
+	if l.useEnv {
+		merge(doc, envDocument(infos, l.envPrefix))
+	}
+	if l.flagSet != nil {
+		merge(doc, flagDocument(l.flagSet, infos))
+	}
+	if l.cliContext != nil {
+		merge(doc, cliDocument(l.cliContext, infos))
+	}
+	if err := interpolate(doc); err != nil {
+		return err
+	}
+	if err := decryptDocument(doc); err != nil {
+		return err
+	}
+
+	l.sources = make(map[string]string)
+	for _, sec := range doc.Sections {
+		for _, e := range sec.Entries {
+			l.sources[sec.Name+"."+e.Key] = e.Source
+		}
+	}
+	return decode(doc, config)
+}
+
+// 配置项的最终来源，如 default、file:app.ini、env:APP_MYSQL_HOST、flag:mysql.host
+func (l *Loader) Source(key string) string {
+	return l.sources[key]
+}
+
+// 所有配置项的来源
+func (l *Loader) Sources() map[string]string {
+	sources := make(map[string]string, len(l.sources))
+	for key, source := range l.sources {
+		sources[key] = source
+	}
+	return sources
+}
+
+// 配置项对应的环境变量名
+func EnvName(prefix string, key string) string {
+	name := strings.NewReplacer(".", "_", "-", "_").Replace(key)
+	if len(prefix) > 0 {
+		name = prefix + "_" + name
+	}
+	return strings.ToUpper(name)
+}
+
+// 是否为布尔配置项
+func isBool(info *fieldInfo) bool {
+	typeInfo := info.Field.Type
+	for typeInfo.Kind() == reflect.Ptr {
+		typeInfo = typeInfo.Elem()
+	}
+	return typeInfo.Kind() == reflect.Bool
+}
+
+// 默认值
+func defaultDocument(infos []*fieldInfo) *document {
+	doc := &document{}
+	for _, info := range infos {
+		if value, ok := info.Field.Tag.Lookup("default"); ok {
+			addEntry(doc, info, value, SourceDefault)
+		}
+	}
+	return doc
+}
+
+// 环境变量
+func envDocument(infos []*fieldInfo, prefix string) *document {
+	doc := &document{}
+	for _, info := range infos {
+		if info.IsMap() {
+			continue
+		}
+		name := EnvName(prefix, info.Path())
+		if value, ok := os.LookupEnv(name); ok {
+			addEntry(doc, info, value, "env:"+name)
+		}
+	}
+	return doc
+}
+
+// 标准库命令行中显式设置的参数
+func flagDocument(fs *flag.FlagSet, infos []*fieldInfo) *document {
+	defined := make(map[string]*fieldInfo)
+	for _, info := range infos {
+		defined[info.Path()] = info
+	}
+	doc := &document{}
+	fs.Visit(func(f *flag.Flag) {
+		if info, ok := defined[f.Name]; ok {
+			addEntry(doc, info, f.Value.String(), "flag:"+f.Name)
+		}
+	})
+	return doc
+}
+
+// urfave/cli 中显式设置的参数
+func cliDocument(c *cli.Context, infos []*fieldInfo) *document {
+	doc := &document{}
+	for _, info := range infos {
+		name := info.Path()
+		var value string
+		switch {
+		case c.IsSet(name) && isBool(info):
+			value = strconv.FormatBool(c.Bool(name))
+		case c.IsSet(name):
+			value = c.String(name)
+		case c.GlobalIsSet(name) && isBool(info):
+			value = strconv.FormatBool(c.GlobalBool(name))
+		case c.GlobalIsSet(name):
+			value = c.GlobalString(name)
+		default:
+			continue
+		}
+		addEntry(doc, info, value, "cli:"+name)
+	}
+	return doc
+}
+
+// 添加配置项
+func addEntry(doc *document, info *fieldInfo, value string, source string) {
+	sec := doc.addSection(info.Section)
+	sec.Entries = append(sec.Entries, &entry{Key: info.Key, Value: value, Source: source})
+}
+
+// 根据配置结构体生成标准库命令行参数，参数名为 section.key
+func DefineFlags(fs *flag.FlagSet, config interface{}) error {
+	if err := checkStructPtr(config); err != nil {
+		return err
+	}
+	for _, info := range fields(reflect.TypeOf(config)) {
+		if info.IsMap() {
+			continue
+		}
+		if isBool(info) {
+			value, _ := strconv.ParseBool(info.Default())
+			fs.Bool(info.Path(), value, info.Desc())
+			continue
+		}
+		fs.String(info.Path(), info.Default(), info.Desc())
+	}
+	return nil
+}
+
+// 根据配置结构体生成 urfave/cli 命令行参数，参数名为 section.key
+func CliFlags(config interface{}) ([]cli.Flag, error) {
+	if err := checkStructPtr(config); err != nil {
+		return nil, err
+	}
+	var flags []cli.Flag
+	for _, info := range fields(reflect.TypeOf(config)) {
+		if info.IsMap() {
+			continue
+		}
+		if isBool(info) {
+			flags = append(flags, cli.BoolFlag{Name: info.Path(), Usage: info.Desc()})
+			continue
+		}
+		flags = append(flags, cli.StringFlag{Name: info.Path(), Value: info.Default(), Usage: info.Desc()})
+	}
+	return flags, nil
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+type ServiceConfig struct {
+	Mysql ServiceMysql `ini:"mysql"`
+	Log   ServiceLog   `ini:"log"`
+}
+
+type ServiceMysql struct {
+	Host    string        `ini:"host" default:"127.0.0.1" desc:"mysql host"`
+	Port    int           `ini:"port" default:"3306" desc:"mysql port"`
+	Timeout time.Duration `ini:"timeout" default:"5s"`
+}
+
+type ServiceLog struct {
+	Level string `ini:"level" default:"info"`
+	Debug bool   `ini:"debug"`
+}
+
+func TestLoader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.ini")
+	writeFile(t, path, "[mysql]\nhost=10.0.0.1\nport=3307\n")
+	if err = os.Setenv("APP_MYSQL_PORT", "3308"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("APP_MYSQL_PORT")
+
+	var config ServiceConfig
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err = DefineFlags(fs, &config); err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.Parse([]string{"-log.level=error", "-log.debug"}); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := NewLoader().AddFile(path).Env("app").FlagSet(fs)
+	if err = loader.Load(&config); err != nil {
+		t.Fatalf("Load failed, err:%v", err)
+	}
+	if config.Mysql.Host != "10.0.0.1" || config.Mysql.Port != 3308 || config.Mysql.Timeout != 5*time.Second {
+		t.Fatalf("Wrong mysql config:%#v", config.Mysql)
+	}
+	if config.Log.Level != "error" || !config.Log.Debug {
+		t.Fatalf("Wrong log config:%#v", config.Log)
+	}
+
+	expected := map[string]string{
+		"mysql.host":    "file:" + path,
+		"mysql.port":    "env:APP_MYSQL_PORT",
+		"mysql.timeout": SourceDefault,
+		"log.level":     "flag:log.level",
+	}
+	for key, source := range expected {
+		if loader.Source(key) != source {
+			t.Fatalf("Wrong source of %s: %s", key, loader.Source(key))
+		}
+	}
+}
+
+func TestLoaderCli(t *testing.T) {
+	var config ServiceConfig
+	flags, err := CliFlags(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := cli.NewApp()
+	app.Flags = flags
+	var loader *Loader
+	app.Action = func(c *cli.Context) error {
+		loader = NewLoader().CliContext(c)
+		return loader.Load(&config)
+	}
+	if err = app.Run([]string{"app", "--mysql.port", "3310", "--log.debug"}); err != nil {
+		t.Fatalf("Run failed, err:%v", err)
+	}
+	if config.Mysql.Port != 3310 || config.Mysql.Host != "127.0.0.1" || !config.Log.Debug {
+		t.Fatalf("Wrong config:%#v", config)
+	}
+	if loader.Source("mysql.port") != "cli:mysql.port" || loader.Source("mysql.host") != SourceDefault {
+		t.Fatalf("Wrong sources:%#v", loader.Sources())
+	}
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 引入其他配置文件的指令
+const includeKey = "include"
+
+// 配置项
+type entry struct {
+	Key    string
+	Value  string
+	Line   int
+	Source string
+}
+
+// 配置节点
+type section struct {
+	Name    string
+	Entries []*entry
+}
+
+// 解析后的配置文档，保持文件中的先后顺序
+type document struct {
+	Includes []string
+	Sections []*section
+}
+
+// 查找节点
+func (d *document) Section(name string) *section {
+	for _, s := range d.Sections {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// 查找节点，不存在则新增
+func (d *document) addSection(name string) *section {
+	s := d.Section(name)
+	if s == nil {
+		s = &section{Name: name}
+		d.Sections = append(d.Sections, s)
+	}
+	return s
+}
+
+// 读取配置项的值，重复配置时取最后一个
+func (s *section) Get(key string) (string, bool) {
+	var (
+		value string
+		found bool
+	)
+	for _, e := range s.Entries {
+		if e.Key == key {
+			value = e.Value
+			found = true
+		}
+	}
+	return value, found
+}
+
+// 读取重复配置的所有值
+func (s *section) Values(key string) []string {
+	var values []string
+	for _, e := range s.Entries {
+		if e.Key == key {
+			values = append(values, e.Value)
+		}
+	}
+	return values
+}
+
+// 是否存在该节点或其子节点
+func (d *document) hasSection(name string) bool {
+	for _, s := range d.Sections {
+		if s.Name == name || strings.HasPrefix(s.Name, name+".") {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+逐行解析 ini 内容：
+[section] 为节点，key=value 为配置项，以 ; 或 # 开头的行为注释，
+文件开头节点之外的 include=path 为引入其他文件
+*/
+func parse(content []byte) (*document, error) {
+	doc := &document{}
+	var current *section
+	lines := strings.Split(string(content), "\n")
+	for index, line := range lines {
+		lineNo := index + 1
+		line = strings.TrimSpace(line)
+		// 空行与注释
+		if len(line) == 0 || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		// 节点
+		if line[0] == '[' {
+			if line[len(line)-1] != ']' {
+				return nil, fmt.Errorf("Syntax error, invalid section:%q, line:%d", line, lineNo)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if len(name) == 0 {
+				return nil, fmt.Errorf("Syntax error, empty section name, line:%d", lineNo)
+			}
+			current = doc.addSection(name)
+			continue
+		}
+
+		// 配置项
+		index := strings.Index(line, "=")
+		if index == -1 {
+			return nil, fmt.Errorf("Syntax error, not found '=', line:%d", lineNo)
+		}
+		key := strings.TrimSpace(line[:index])
+		value := strings.TrimSpace(line[index+1:])
+		if len(key) == 0 {
+			return nil, fmt.Errorf("Syntax error, empty key, line:%d", lineNo)
+		}
+		if current == nil && key == includeKey {
+			doc.Includes = append(doc.Includes, value)
+			continue
+		}
+		if current == nil {
+			return nil, fmt.Errorf("Syntax error, key %q outside of section, line:%d", key, lineNo)
+		}
+		current.Entries = append(current.Entries, &entry{Key: key, Value: value, Line: lineNo})
+	}
+	return doc, nil
+}
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 远程配置请求超时时间
+const RemoteTimeout = 10 * time.Second
+
+// 远程配置获取失败、改用本地缓存时返回的错误，此时配置已按缓存解析
+type CacheError struct {
+	URL string
+	Err error
+}
+
+func (e *CacheError) Error() string {
+	return fmt.Sprintf("Using cached config[%s], %v", e.URL, e.Err)
+}
+
+func (e *CacheError) Unwrap() error {
+	return e.Err
+}
+
+/*
+远程配置来源：
+通过 ETag/If-None-Match 请求配置服务，未变化时服务端返回 304，
+每次成功获取后写入本地缓存，服务不可用时使用最后一份可用的缓存并返回 *CacheError；
+远程配置由服务端控制，不支持 include，也不展开 ${ENV:...}，避免读取客户端的本地文件与环境变量
+*/
+type remoteSource struct {
+	url       string
+	cachePath string
+	client    *http.Client
+	mu        sync.Mutex
+	etag      string
+	content   []byte
+}
+
+/*
+监听远程配置，cachePath 为本地缓存文件，为空时不缓存；
+首次获取失败但有缓存时返回可用的 Watcher 与 *CacheError，之后的失败通过 OnError 报告
+*/
+func WatchRemote(url string, cachePath string, config interface{}, interval time.Duration, onChange Subscriber) (*Watcher, error) {
+	if err := checkStructPtr(config); err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		return nil, errors.New("Watch interval must be positive")
+	}
+	source := &remoteSource{
+		url:       url,
+		cachePath: cachePath,
+		client:    &http.Client{Timeout: RemoteTimeout},
+	}
+	source.loadCache()
+	return newWatcher(source.load, config, interval, onChange)
+}
+
+// 一次性读取远程配置，服务不可用时按缓存解析并返回 *CacheError
+func UnMarshalRemote(url string, cachePath string, config interface{}) error {
+	if err := checkStructPtr(config); err != nil {
+		return err
+	}
+	source := &remoteSource{
+		url:       url,
+		cachePath: cachePath,
+		client:    &http.Client{Timeout: RemoteTimeout},
+	}
+	source.loadCache()
+	doc, _, err := source.load()
+	if doc == nil {
+		return err
+	}
+	if decodeErr := decode(doc, config); decodeErr != nil {
+		return decodeErr
+	}
+	return err
+}
+
+// 获取配置并解析，使用缓存时同时返回文档与 *CacheError
+func (r *remoteSource) load() (*document, []byte, error) {
+	content, err := r.fetch()
+	if content == nil {
+		return nil, nil, err
+	}
+	doc, parseErr := readRemoteDocument(content, r.url)
+	if parseErr != nil {
+		return nil, nil, parseErr
+	}
+	return doc, content, err
+}
+
+// 请求配置服务，失败时返回最后一份可用的内容与 *CacheError
+func (r *remoteSource) fetch() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	content, err := r.request()
+	if err == nil {
+		return content, nil
+	}
+	if r.content != nil {
+		return r.content, &CacheError{URL: r.url, Err: err}
+	}
+	return nil, err
+}
+
+// 解析远程配置，include 与 ${ENV:...} 视为错误
+func readRemoteDocument(content []byte, url string) (*document, error) {
+	doc, err := parse(content)
+	if err != nil {
+		return nil, err
+	}
+	if len(doc.Includes) > 0 {
+		return nil, fmt.Errorf("Include is not allowed in remote config[%s]", url)
+	}
+	for _, sec := range doc.Sections {
+		for _, e := range sec.Entries {
+			e.Source = "remote:" + url
+		}
+	}
+	if err = interpolateWith(doc, false); err != nil {
+		return nil, err
+	}
+	if err = decryptDocument(doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// 发送请求，304 时返回当前内容
+func (r *remoteSource) request() ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.etag) > 0 && r.content != nil {
+		req.Header.Set("If-None-Match", r.etag)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to request config[%s], err:%v", r.url, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && r.content != nil:
+		return r.content, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("Failed to request config[%s], status:%s", r.url, resp.Status)
+	}
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read config[%s], err:%v", r.url, err)
+	}
+	// 解析失败的内容不缓存，保留上一份可用配置
+	if _, err = readRemoteDocument(content, r.url); err != nil {
+		return nil, fmt.Errorf("Invalid config[%s], err:%v", r.url, err)
+	}
+	r.etag = resp.Header.Get("ETag")
+	r.content = content
+	r.saveCache()
+	return content, nil
+}
+
+// 缓存中的 ETag 文件
+func (r *remoteSource) etagPath() string {
+	return r.cachePath + ".etag"
+}
+
+// 读取本地缓存
+func (r *remoteSource) loadCache() {
+	if len(r.cachePath) == 0 {
+		return
+	}
+	content, err := ioutil.ReadFile(r.cachePath)
+	if err != nil {
+		return
+	}
+	r.content = content
+	if etag, err := ioutil.ReadFile(r.etagPath()); err == nil {
+		r.etag = strings.TrimSpace(string(etag))
+	}
+}
+
+// 写入本地缓存，先写临时文件再重命名，避免进程退出时留下不完整的缓存
+func (r *remoteSource) saveCache() {
+	if len(r.cachePath) == 0 {
+		return
+	}
+	if err := writeFileAtomic(r.cachePath, r.content); err != nil {
+		return
+	}
+	_ = writeFileAtomic(r.etagPath(), []byte(r.etag))
+}
+
+// 原子写文件
+func writeFileAtomic(path string, content []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 模拟配置服务
+type configServer struct {
+	mu          sync.Mutex
+	content     string
+	etag        string
+	down        bool
+	notModified int
+}
+
+func (s *configServer) set(content, etag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.content, s.etag = content, etag
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if r.Header.Get("If-None-Match") == s.etag {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", s.etag)
+	_, _ = w.Write([]byte(s.content))
+}
+
+func TestWatchRemote(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cachePath := filepath.Join(dir, "remote.ini")
+
+	server := &configServer{}
+	server.set("[log]\nlevel=debug\n", `"v1"`)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	var config LogConfig
+	changed := make(chan []Change, 1)
+	w, err := WatchRemote(ts.URL, cachePath, &config, 10*time.Millisecond, nil)
+	if err != nil {
+		t.Fatalf("WatchRemote failed, err:%v", err)
+	}
+	defer w.Close()
+	w.Subscribe(func(old, new interface{}, changes []Change) {
+		changed <- changes
+	})
+	if config.Log.Level != "debug" {
+		t.Fatalf("Wrong initial config:%#v", config)
+	}
+
+	// 未变化时返回 304
+	deadline := time.Now().Add(time.Second)
+	for {
+		server.mu.Lock()
+		notModified := server.notModified
+		server.mu.Unlock()
+		if notModified > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("If-None-Match not sent")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	server.set("[log]\nlevel=error\n", `"v2"`)
+	select {
+	case changes := <-changed:
+		if len(changes) != 1 || changes[0].New != "error" {
+			t.Fatalf("Wrong changes:%#v", changes)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Remote change not notified")
+	}
+
+	// 服务不可用时使用缓存，同时报告错误
+	failed := make(chan error, 1)
+	w.OnError(func(err error) {
+		select {
+		case failed <- err:
+		default:
+		}
+	})
+	server.mu.Lock()
+	server.down = true
+	server.mu.Unlock()
+	select {
+	case err = <-failed:
+		if _, ok := err.(*CacheError); !ok {
+			t.Fatalf("Wrong error:%v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Remote failure not reported")
+	}
+	if w.Current().(*LogConfig).Log.Level != "error" {
+		t.Fatalf("Wrong current config:%#v", w.Current())
+	}
+	var cached LogConfig
+	if err = UnMarshalRemote(ts.URL, cachePath, &cached); err == nil {
+		t.Fatal("Cache fallback should report the fetch error")
+	} else if _, ok := err.(*CacheError); !ok {
+		t.Fatalf("Cache fallback failed, err:%v", err)
+	}
+	if cached.Log.Level != "error" {
+		t.Fatalf("Wrong cached config:%#v", cached)
+	}
+	if err = UnMarshalRemote(ts.URL, "", &cached); err == nil {
+		t.Fatal("Without cache should fail when server is down")
+	}
+}
+
+func TestRemoteUntrustedContent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	local := filepath.Join(dir, "local.ini")
+	writeFile(t, local, "[log]\nlevel=secret\n")
+	if err = os.Setenv("CONFIG_REMOTE_TEST", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("CONFIG_REMOTE_TEST")
+
+	server := &configServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	for _, content := range []string{
+		"include=" + local + "\n[log]\nlevel=debug\n",
+		"[log]\nlevel=${ENV:CONFIG_REMOTE_TEST}\n",
+		"[log]\nlevel=${ENV:CONFIG_REMOTE_TEST:-debug}\n",
+	} {
+		server.set(content, `"v1"`)
+		var config LogConfig
+		if err = UnMarshalRemote(ts.URL, "", &config); err == nil || config.Log.Level == "secret" {
+			t.Fatalf("Untrusted remote content accepted:%q, config:%#v", content, config)
+		}
+	}
+
+	// 配置项之间的引用仍然可用
+	server.set("[log]\nbase=debug\nlevel=${log.base}\n", `"v2"`)
+	var config LogConfig
+	if err = UnMarshalRemote(ts.URL, "", &config); err != nil || config.Log.Level != "debug" {
+		t.Fatalf("Wrong config:%#v, err:%v", config, err)
+	}
+}
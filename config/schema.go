@@ -0,0 +1,79 @@
+package config
+
+import (
+	"reflect"
+)
+
+// 配置项定义，对应结构体中的一个叶子字段
+type fieldInfo struct {
+	Section string
+	Key     string
+	Field   reflect.StructField
+}
+
+// 完整配置名 section.key
+func (f *fieldInfo) Path() string {
+	return f.Section + "." + f.Key
+}
+
+// 默认值，通过 default 标签指定
+func (f *fieldInfo) Default() string {
+	return f.Field.Tag.Get("default")
+}
+
+// 配置说明，通过 desc 标签指定
+func (f *fieldInfo) Desc() string {
+	return f.Field.Tag.Get("desc")
+}
+
+// 是否为映射字段，映射字段以 key.sub 形式配置
+func (f *fieldInfo) IsMap() bool {
+	typeInfo := f.Field.Type
+	for typeInfo.Kind() == reflect.Ptr {
+		typeInfo = typeInfo.Elem()
+	}
+	return typeInfo.Kind() == reflect.Map
+}
+
+// 按定义顺序列出配置结构体的所有配置项
+func fields(typeInfo reflect.Type) []*fieldInfo {
+	for typeInfo.Kind() == reflect.Ptr {
+		typeInfo = typeInfo.Elem()
+	}
+	var result []*fieldInfo
+	collectFields(typeInfo, "", &result)
+	return result
+}
+
+// 递归收集配置项，与 decodeStruct 的节点规则一致，节点内的配置项排在子节点之前
+func collectFields(typeInfo reflect.Type, prefix string, result *[]*fieldInfo) {
+	var subSections []reflect.StructField
+	for i := 0; i < typeInfo.NumField(); i++ {
+		field := typeInfo.Field(i)
+		name, ok := fieldName(field)
+		if !ok {
+			continue
+		}
+		if isSection(field.Type) {
+			subSections = append(subSections, field)
+			continue
+		}
+		if len(prefix) == 0 {
+			continue
+		}
+		*result = append(*result, &fieldInfo{Section: prefix, Key: name, Field: field})
+	}
+
+	for _, field := range subSections {
+		name, _ := fieldName(field)
+		path := name
+		if len(prefix) > 0 {
+			path = prefix + "." + name
+		}
+		sectionType := field.Type
+		if sectionType.Kind() == reflect.Ptr {
+			sectionType = sectionType.Elem()
+		}
+		collectFields(sectionType, path, result)
+	}
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// 密钥环境变量，值为 base64 编码的 16/24/32 字节 AES 密钥
+	KeyEnv = "CONFIG_SECRET_KEY"
+	// 密钥文件环境变量，文件内容同 KeyEnv
+	KeyFileEnv = "CONFIG_SECRET_KEY_FILE"
+
+	encPrefix = "ENC("
+	encSuffix = ")"
+)
+
+// 读取密钥，环境变量优先，其次为密钥文件
+func LoadSecretKey() ([]byte, error) {
+	encoded := os.Getenv(KeyEnv)
+	if len(encoded) == 0 {
+		path := os.Getenv(KeyFileEnv)
+		if len(path) == 0 {
+			return nil, fmt.Errorf("Secret key not found, please set %s or %s", KeyEnv, KeyFileEnv)
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read key file[%s]", path)
+		}
+		encoded = string(content)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, errors.New("Secret key must be base64 encoded")
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	}
+	return nil, fmt.Errorf("Invalid secret key length %d, must be 16, 24 or 32 bytes", len(key))
+}
+
+// 生成随机的 32 字节密钥，返回 base64 编码
+func GenerateSecretKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// 使用 AES-GCM 加密，返回 ENC(base64(nonce+密文)) 形式的配置值
+func Encrypt(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encPrefix + base64.StdEncoding.EncodeToString(sealed) + encSuffix, nil
+}
+
+// 解密 ENC(...) 形式的配置值
+func Decrypt(key []byte, value string) (string, error) {
+	if !isEncrypted(value) {
+		return "", errors.New("Value is not ENC(...) encrypted")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(value[len(encPrefix) : len(value)-len(encSuffix)])
+	if err != nil {
+		return "", errors.New("Encrypted value must be base64 encoded")
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("Encrypted value too short")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("Failed to decrypt value, wrong key or corrupted data")
+	}
+	return string(plaintext), nil
+}
+
+// 是否为加密的配置值
+func isEncrypted(value string) bool {
+	return strings.HasPrefix(value, encPrefix) && strings.HasSuffix(value, encSuffix)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// 解密文档中所有 ENC(...) 配置值，仅在存在加密值时读取密钥
+func decryptDocument(doc *document) error {
+	var key []byte
+	for _, sec := range doc.Sections {
+		for _, e := range sec.Entries {
+			if !isEncrypted(e.Value) {
+				continue
+			}
+			if key == nil {
+				var err error
+				if key, err = LoadSecretKey(); err != nil {
+					return err
+				}
+			}
+			value, err := Decrypt(key, e.Value)
+			if err != nil {
+				return fmt.Errorf("Failed to decrypt %s.%s, line:%d: %v", sec.Name, e.Key, e.Line, err)
+			}
+			e.Value = value
+		}
+	}
+	return nil
+}
+
+// 敏感配置值，fmt 的任意格式都只输出掩码，明文通过 string(s) 获取
+type Secret string
+
+var secretType = reflect.TypeOf(Secret(""))
+
+func (s Secret) String() string {
+	return SecretMask
+}
+
+func (s Secret) GoString() string {
+	return strconv.Quote(SecretMask)
+}
+
+func (s Secret) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		io.WriteString(f, s.GoString())
+		return
+	}
+	io.WriteString(f, SecretMask)
+}
+
+// 是否为敏感字段：Secret 类型或设置了 secret:"true"
+func isSecret(field reflect.StructField) bool {
+	if field.Tag.Get("secret") == "true" {
+		return true
+	}
+	typeInfo := field.Type
+	for typeInfo.Kind() == reflect.Ptr || typeInfo.Kind() == reflect.Slice || typeInfo.Kind() == reflect.Map {
+		typeInfo = typeInfo.Elem()
+	}
+	return typeInfo == secretType
+}
+
+/*
+按 %+v 的格式输出配置结构体，敏感字段以掩码代替，包括指针指向的节点；
+string 类型的 secret:"true" 字段只在 Sprint 与 Dump 中掩码，需要 fmt 直接输出时使用 Secret 类型
+*/
+func Sprint(config interface{}) string {
+	var builder strings.Builder
+	sprintValue(&builder, reflect.ValueOf(config))
+	return builder.String()
+}
+
+func sprintValue(builder *strings.Builder, value reflect.Value) {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			builder.WriteString("<nil>")
+			return
+		}
+		builder.WriteString("&")
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct || !isSection(value.Type()) {
+		fmt.Fprintf(builder, "%+v", value.Interface())
+		return
+	}
+
+	typeInfo := value.Type()
+	builder.WriteString("{")
+	for i := 0; i < typeInfo.NumField(); i++ {
+		field := typeInfo.Field(i)
+		if i > 0 {
+			builder.WriteString(" ")
+		}
+		builder.WriteString(field.Name + ":")
+		fieldValue := value.Field(i)
+		switch {
+		case field.PkgPath != "":
+			// 非导出字段不输出内容
+			builder.WriteString("-")
+		case isSecret(field):
+			var secret string
+			if v := reflect.Indirect(fieldValue); v.IsValid() {
+				secret = fmt.Sprint(v.Interface())
+			}
+			builder.WriteString(maskValue(true, secret))
+		case isSection(field.Type):
+			sprintValue(builder, fieldValue)
+		default:
+			fmt.Fprintf(builder, "%+v", fieldValue.Interface())
+		}
+	}
+	builder.WriteString("}")
+}
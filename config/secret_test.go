@@ -0,0 +1,93 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	encoded, err := GenerateSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := base64.StdEncoding.DecodeString(encoded)
+	value, err := Encrypt(key, "root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(value, "ENC(") || strings.Contains(value, "root") {
+		t.Fatalf("Wrong encrypted value:%s", value)
+	}
+	plaintext, err := Decrypt(key, value)
+	if err != nil || plaintext != "root" {
+		t.Fatalf("Decrypt failed, plaintext:%s, err:%v", plaintext, err)
+	}
+	other, _ := GenerateSecretKey()
+	otherKey, _ := base64.StdEncoding.DecodeString(other)
+	if _, err = Decrypt(otherKey, value); err == nil {
+		t.Fatal("Decrypt with wrong key should fail")
+	}
+}
+
+func TestDecodeEncryptedValue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	encoded, _ := GenerateSecretKey()
+	keyFile := filepath.Join(dir, "secret.key")
+	writeFile(t, keyFile, encoded+"\n")
+	if err = os.Setenv(KeyFileEnv, keyFile); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv(KeyFileEnv)
+
+	key, err := LoadSecretKey()
+	if err != nil {
+		t.Fatalf("LoadSecretKey failed, err:%v", err)
+	}
+	value, _ := Encrypt(key, "p@ss")
+	path := filepath.Join(dir, "app.ini")
+	writeFile(t, path, "[mysql]\nhost=db\npassword="+value+"\n")
+
+	var config SecretConfig
+	if err = UnMarshalFile(path, &config); err != nil {
+		t.Fatalf("UnMarshalFile failed, err:%v", err)
+	}
+	if config.Mysql.Password != "p@ss" {
+		t.Fatalf("Wrong password:%s", config.Mysql.Password)
+	}
+
+	printed := Sprint(config) + Sprint(&config) + Sprint(config.Mysql)
+	if strings.Contains(printed, "p@ss") || strings.Count(printed, "Password:"+SecretMask) != 3 {
+		t.Fatalf("Secret not masked:%s", printed)
+	}
+
+	// fmt 直接输出时 Secret 类型同样掩码
+	printed = fmt.Sprintf("%v/%+v/%#v/%s/%q", config, &config, config, config.Mysql.Password, config.Mysql.Password)
+	if strings.Contains(printed, "p@ss") || strings.Count(printed, SecretMask) != 5 {
+		t.Fatalf("Secret not masked:%s", printed)
+	}
+
+	// 指针指向的节点同样掩码
+	nested := struct {
+		Mysql *SecretMysql `ini:"mysql"`
+	}{Mysql: &config.Mysql}
+	if printed = Sprint(&nested); strings.Contains(printed, "p@ss") || !strings.Contains(printed, "Password:"+SecretMask) {
+		t.Fatalf("Secret not masked:%s", printed)
+	}
+
+	// 变更列表中的敏感配置以掩码代替，但仍能发现变化
+	changed := config
+	changed.Mysql.Password = "new"
+	changes := Diff(&config, &changed)
+	if len(changes) != 1 || changes[0].Key != "mysql.password" || changes[0].Old != SecretMask || changes[0].New != SecretMask {
+		t.Fatalf("Wrong changes:%+v", changes)
+	}
+}
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 默认轮询间隔
+const DefaultInterval = 2 * time.Second
+
+// 配置项变更，敏感字段的值以掩码代替
+type Change struct {
+	Key string
+	Old string
+	New string
+}
+
+// 配置变更订阅函数，changes 为发生变化的配置项
+type Subscriber func(old, new interface{}, changes []Change)
+
+/*
+配置热加载：
+定时轮询配置来源（本地文件或 HTTP 地址），内容变化后解析到新的结构体并校验，
+校验通过才替换当前配置并通知订阅者，失败则保留旧配置
+*/
+type Watcher struct {
+	load        func() (*document, []byte, error)
+	interval    time.Duration
+	typeInfo    reflect.Type
+	current     atomic.Value
+	content     []byte
+	mu          sync.Mutex
+	subscribers []Subscriber
+	onError     func(err error)
+	done        chan struct{}
+	once        sync.Once
+}
+
+// 监听配置文件，使用默认轮询间隔
+func Watch(path string, config interface{}, onChange Subscriber) (*Watcher, error) {
+	return WatchInterval(path, config, DefaultInterval, onChange)
+}
+
+// 监听配置文件，config 为首次加载的结构体指针，之后的版本通过 Current 或订阅获取，onChange 不为空时作为第一个订阅者
+func WatchInterval(path string, config interface{}, interval time.Duration, onChange Subscriber) (*Watcher, error) {
+	if err := checkStructPtr(config); err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		return nil, errors.New("Watch interval must be positive")
+	}
+	return newWatcher(func() (*document, []byte, error) {
+		return loadFile(path)
+	}, config, interval, onChange)
+}
+
+// 首次加载并启动轮询，load 返回解析后的文档与用于判断变化的原始内容
+func newWatcher(load func() (*document, []byte, error), config interface{}, interval time.Duration, onChange Subscriber) (*Watcher, error) {
+	doc, content, err := load()
+	cacheErr, cached := err.(*CacheError)
+	if err != nil && !cached {
+		return nil, err
+	}
+	if err = decode(doc, config); err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		load:     load,
+		interval: interval,
+		typeInfo: reflect.TypeOf(config).Elem(),
+		content:  content,
+		done:     make(chan struct{}),
+	}
+	w.current.Store(config)
+	if onChange != nil {
+		w.Subscribe(onChange)
+	}
+	go w.run()
+
+	if cached {
+		return w, cacheErr
+	}
+	return w, nil
+}
+
+// 当前生效的配置，返回值与传入 Watch 的指针类型一致
+func (w *Watcher) Current() interface{} {
+	return w.current.Load()
+}
+
+// 订阅配置变更
+func (w *Watcher) Subscribe(fn Subscriber) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// 设置重新加载失败时的处理函数
+func (w *Watcher) OnError(fn func(err error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onError = fn
+}
+
+// 停止监听
+func (w *Watcher) Close() {
+	w.once.Do(func() {
+		close(w.done)
+	})
+}
+
+// 后台轮询
+func (w *Watcher) run() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			if err := w.Reload(); err != nil {
+				w.mu.Lock()
+				onError := w.onError
+				w.mu.Unlock()
+				if onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}
+}
+
+/*
+重新读取配置来源，内容变化时重新加载，订阅者在释放锁后调用，可以在回调中再次订阅或重新加载；
+远程配置改用缓存时照常加载缓存内容，并返回 *CacheError
+*/
+func (w *Watcher) Reload() error {
+	doc, content, err := w.load()
+	cacheErr, cached := err.(*CacheError)
+	if err != nil && !cached {
+		return err
+	}
+	if err = w.apply(doc, content); err != nil {
+		return err
+	}
+	if cached {
+		return cacheErr
+	}
+	return nil
+}
+
+// 内容变化时解析并替换当前配置，通知订阅者
+func (w *Watcher) apply(doc *document, content []byte) error {
+	w.mu.Lock()
+	if bytes.Equal(content, w.content) {
+		w.mu.Unlock()
+		return nil
+	}
+	// 同一份内容校验失败只报告一次
+	w.content = content
+	config := reflect.New(w.typeInfo).Interface()
+	if err := decode(doc, config); err != nil {
+		w.mu.Unlock()
+		return err
+	}
+
+	old := w.current.Load()
+	changes := Diff(old, config)
+	if len(changes) == 0 {
+		w.mu.Unlock()
+		return nil
+	}
+	w.current.Store(config)
+	subscribers := make([]Subscriber, len(w.subscribers))
+	copy(subscribers, w.subscribers)
+	w.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(old, config, changes)
+	}
+	return nil
+}
+
+// 比较两份配置，返回按配置名排序的变更列表，敏感配置按原值比较、以掩码输出
+func Diff(old, new interface{}) []Change {
+	oldValues, oldSecrets := flatten(old)
+	newValues, newSecrets := flatten(new)
+	var changes []Change
+	for key, value := range newValues {
+		if oldValue, ok := oldValues[key]; !ok || oldValue != value {
+			changes = append(changes, Change{Key: key, Old: oldValue, New: value})
+		}
+	}
+	for key, value := range oldValues {
+		if _, ok := newValues[key]; !ok {
+			changes = append(changes, Change{Key: key, Old: value})
+		}
+	}
+	for i := range changes {
+		secret := oldSecrets[changes[i].Key] || newSecrets[changes[i].Key]
+		changes[i].Old = maskValue(secret, changes[i].Old)
+		changes[i].New = maskValue(secret, changes[i].New)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type LogConfig struct {
+	Log Log `ini:"log"`
+}
+
+type Log struct {
+	Level string `ini:"level"`
+	Path  string `ini:"path"`
+}
+
+// 只允许已知的日志级别
+func (c *LogConfig) Validate() error {
+	switch c.Log.Level {
+	case "debug", "info", "error":
+		return nil
+	}
+	return errors.New("invalid log level")
+}
+
+func writeFile(t *testing.T, path, content string) {
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Write file failed, err:%v", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.ini")
+	writeFile(t, path, "[log]\nlevel=debug\npath=/tmp\n")
+
+	var config LogConfig
+	changed := make(chan []Change, 1)
+	failed := make(chan error, 1)
+	// onChange 与订阅者一样收到变更列表
+	w, err := WatchInterval(path, &config, 10*time.Millisecond, func(old, new interface{}, changes []Change) {
+		changed <- changes
+	})
+	if err != nil {
+		t.Fatalf("Watch failed, err:%v", err)
+	}
+	defer w.Close()
+	w.OnError(func(err error) {
+		select {
+		case failed <- err:
+		default:
+		}
+	})
+	if config.Log.Level != "debug" {
+		t.Fatalf("Initial load wrong value, config:%#v", config)
+	}
+
+	// 非法配置不会替换
+	writeFile(t, path, "[log]\nlevel=verbose\npath=/tmp\n")
+	select {
+	case <-failed:
+	case <-time.After(time.Second):
+		t.Fatal("Invalid config not reported")
+	}
+	if w.Current().(*LogConfig).Log.Level != "debug" {
+		t.Fatalf("Invalid config swapped in, config:%#v", w.Current())
+	}
+
+	writeFile(t, path, "[log]\nlevel=error\npath=/tmp\n")
+	select {
+	case changes := <-changed:
+		if len(changes) != 1 || changes[0].Key != "log.level" || changes[0].Old != "debug" || changes[0].New != "error" {
+			t.Fatalf("Wrong changes:%#v", changes)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Change not notified")
+	}
+	if w.Current().(*LogConfig).Log.Level != "error" {
+		t.Fatalf("Config not swapped, config:%#v", w.Current())
+	}
+}
+
+func TestWatchSubscriberReentrant(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.ini")
+	writeFile(t, path, "[log]\nlevel=debug\npath=/tmp\n")
+
+	var config LogConfig
+	w, err := WatchInterval(path, &config, time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	called := make(chan struct{}, 1)
+	// 回调中再次订阅与重新加载不会死锁
+	w.Subscribe(func(old, new interface{}, changes []Change) {
+		w.Subscribe(func(old, new interface{}, changes []Change) {})
+		w.OnError(func(err error) {})
+		if err := w.Reload(); err != nil {
+			t.Error(err)
+		}
+		called <- struct{}{}
+	})
+
+	writeFile(t, path, "[log]\nlevel=info\npath=/tmp\n")
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Reload()
+	}()
+	select {
+	case err = <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Reload deadlocked")
+	}
+	<-called
+	if w.Current().(*LogConfig).Log.Level != "info" {
+		t.Fatalf("Wrong config:%+v", w.Current())
+	}
+}
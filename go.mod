@@ -6,4 +6,5 @@ require (
 	github.com/gin-gonic/gin v1.6.3
 	github.com/go-sql-driver/mysql v1.5.0
 	github.com/urfave/cli v1.22.4
+	golang.org/x/sys v0.0.0-20200116001909-b77594299b42
 )